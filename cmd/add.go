@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/longkey1/lnkr/internal/lnkr"
 	"github.com/spf13/cobra"
@@ -9,25 +10,45 @@ import (
 
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:   "add [path]",
-	Short: "Move a file/directory to remote and link it back to local",
-	Long: `Move a local file/directory to the remote directory and replace it with a
-link pointing back to the moved file. The entry is recorded in .lnkr.toml.
+	Use:   "add [path...]",
+	Short: "Move files/directories to remote and link them back to local",
+	Long: `Move local files/directories to the remote directory and replace them with
+links pointing back to the moved files. The entries are recorded in .lnkr.toml.
 
-The path may be absolute or relative to the current directory, as long as it
-is inside the local directory.
+Paths may be absolute or relative to the current directory, as long as they
+are inside the local directory. Quoted glob patterns like 'config/*.local.yaml'
+or '**/.envrc' are expanded relative to the current directory, skipping ignored
+paths and existing entries. With --from-file, paths are also read from a file
+("-" for stdin), one per line or NUL-separated with -0.
+
+All paths are checked before anything is moved, and they are added in one
+operation.
 
 This command will:
 - Move the specified local file/directory to the remote directory
 - Create a link from remote to local
 - Add the entry to .lnkr.toml configuration
 - If recursive flag is set with hard links, it will also add all files in the directory`,
-	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		recursive, _ := cmd.Flags().GetBool("recursive")
 		linkTypeFlag, _ := cmd.Flags().GetString("type")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
-		path := args[0]
+		fromFile, _ := cmd.Flags().GetString("from-file")
+		null, _ := cmd.Flags().GetBool("null")
+
+		paths := args
+		if fromFile != "" {
+			listed, err := readPathList(cmd, fromFile, null)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, listed...)
+		} else if null {
+			return fmt.Errorf("-0 can only be used with --from-file")
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("requires at least one path or --from-file")
+		}
 
 		// Load config to get default link type
 		config, err := lnkr.LoadConfigForCLI()
@@ -38,8 +59,8 @@ This command will:
 		// Determine link type: use flag if explicitly set, otherwise use config default
 		linkType := config.GetLinkType()
 		if linkTypeFlag != "" {
-			if linkTypeFlag != lnkr.LinkTypeSymbolic && linkTypeFlag != lnkr.LinkTypeHard && linkTypeFlag != "symbolic" {
-				return fmt.Errorf("invalid link type %q. Must be 'sym' or 'hard'", linkTypeFlag)
+			if !lnkr.ValidLinkType(linkTypeFlag) && linkTypeFlag != "symbolic" {
+				return fmt.Errorf("invalid link type %q. Must be 'sym', 'hard', 'copy' or 'reflink'", linkTypeFlag)
 			}
 			// Normalize "symbolic" to "sym"
 			if linkTypeFlag == "symbolic" {
@@ -49,15 +70,30 @@ This command will:
 			}
 		}
 
-		return lnkr.Add(path, recursive, linkType, dryRun)
+		return lnkr.Add(paths, recursive, linkType, dryRun)
 	},
 }
 
+// readPathList reads the paths listed in file, or in stdin for "-".
+func readPathList(cmd *cobra.Command, file string, null bool) ([]string, error) {
+	if file == "-" {
+		return lnkr.ReadPathList(cmd.InOrStdin(), null)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open path list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return lnkr.ReadPathList(f, null)
+}
+
 func init() {
 	rootCmd.AddCommand(addCmd)
 
 	// Add flags
 	addCmd.Flags().BoolP("recursive", "r", false, "Add recursively (include all files in directory, for hard links)")
-	addCmd.Flags().StringP("type", "t", "", "Link type: 'sym' or 'hard' (default: config setting or sym)")
+	addCmd.Flags().StringP("type", "t", "", "Link type: 'sym', 'hard', 'copy' or 'reflink' (default: config setting or sym)")
 	addCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	addCmd.Flags().String("from-file", "", "Read paths to add from a file, one per line ('-' for stdin)")
+	addCmd.Flags().BoolP("null", "0", false, "Paths in --from-file are separated by NUL characters")
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Register files that already exist in remote and link them locally",
+	Long: `Record a file or directory that already exists in the remote directory
+(e.g. synced from another machine) as an entry in .lnkr.toml and link it
+locally. Unlike 'lnkr add', nothing is moved.
+
+The path may be the remote path, or the local path the entry will have.
+
+With --all, every path under the remote directory that no entry covers is
+adopted. Directories without entries in them are adopted as a whole, except
+with hard links, which are recorded file by file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		linkTypeFlag, _ := cmd.Flags().GetString("type")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if all == (len(args) == 1) {
+			return fmt.Errorf("specify either a path or --all")
+		}
+		var path string
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		// Load config to get default link type
+		config, err := lnkr.LoadConfigForCLI()
+		if err != nil {
+			return err
+		}
+
+		// Determine link type: use flag if explicitly set, otherwise use config default
+		linkType := config.GetLinkType()
+		if linkTypeFlag != "" {
+			if !lnkr.ValidLinkType(linkTypeFlag) && linkTypeFlag != "symbolic" {
+				return fmt.Errorf("invalid link type %q. Must be 'sym', 'hard', 'copy' or 'reflink'", linkTypeFlag)
+			}
+			linkType = linkTypeFlag
+		}
+
+		return lnkr.Adopt(path, all, linkType, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+	adoptCmd.Flags().Bool("all", false, "Adopt every unmanaged path under the remote directory")
+	adoptCmd.Flags().StringP("type", "t", "", "Link type: 'sym', 'hard', 'copy' or 'reflink' (default: config setting or sym)")
+	adoptCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [path...]",
+	Short: "Show how local files differ from remote",
+	Long: `Show unified diffs from the remote to the local version of every entry that
+is not an identical link, e.g. a diverged hard link or a local file blocking
+'lnkr link'. Directories are compared recursively and binary files are
+summarized by size and hash.
+
+Paths select entries, or files and directories inside entries; without paths
+all entries are compared. Diffs are colored on a terminal unless NO_COLOR is
+set or --color says otherwise.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stat, _ := cmd.Flags().GetBool("stat")
+		color, _ := cmd.Flags().GetString("color")
+		return lnkr.Diff(args, stat, color)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().Bool("stat", false, "Show the number of changed lines per file instead of diffs")
+	diffCmd.Flags().String("color", lnkr.ColorAuto, "Color diffs: auto, always or never")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration, remote directory, git exclusions and links",
+	Long: `Check everything lnkr depends on and suggest a fix for each problem:
+
+  - .lnkr.toml parses, its entries are valid and the variables in local and
+    remote expand
+  - no operation was interrupted
+  - the remote directory exists and is writable
+  - .lnkr.toml is a symbolic link to its copy in remote
+  - the LNKR section of the git exclude file matches the entries
+  - every link is healthy
+
+Exit codes:
+  0  all checks passed
+  1  problems were found
+  2  the configuration is unusable, other checks were skipped`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return lnkr.Doctor()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Find orphaned remote files and entries whose remote path is gone",
+	Long: `Walk the remote directory against the entries in .lnkr.toml and report:
+
+  - orphans: paths in remote that no entry covers, e.g. leftovers from manual
+    edits, aborted adds or entries removed on another machine
+  - dead entries: entries whose remote path no longer exists
+
+Without flags, nothing is changed.
+
+  --archive   move orphans to .lnkr-archive/<timestamp>/ in remote
+  --delete    delete orphans
+  --prune     drop dead entries from .lnkr.toml
+
+Changes are confirmed before they are applied unless --yes is given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		archive, _ := cmd.Flags().GetBool("archive")
+		del, _ := cmd.Flags().GetBool("delete")
+		prune, _ := cmd.Flags().GetBool("prune")
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		orphans := lnkr.OrphansReport
+		if archive {
+			orphans = lnkr.OrphansArchive
+		} else if del {
+			orphans = lnkr.OrphansDelete
+		}
+		return lnkr.GC(orphans, prune, yes, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().Bool("archive", false, "Move orphans to the archive directory in remote")
+	gcCmd.Flags().Bool("delete", false, "Delete orphans")
+	gcCmd.Flags().Bool("prune", false, "Drop entries whose remote path is gone")
+	gcCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	gcCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	gcCmd.MarkFlagsMutuallyExclusive("archive", "delete")
+}
@@ -6,18 +6,39 @@ import (
 )
 
 var linkCmd = &cobra.Command{
-	Use:   "link",
+	Use:   "link [path...]",
 	Short: "Create links based on .lnkr.toml configuration",
 	Long: `Create hard links or symbolic links based on the .lnkr.toml configuration file.
 
 Links are created from the remote directory (source) to the local directory.
 Already-linked entries are skipped, so the command can be re-run safely.
-A local file that exists but is not a link to remote is reported as an error.`,
+All entries are linked unless paths or selector flags select some of them.
+With --only-available, entries whose remote path does not exist yet (e.g.
+after a partial sync) are skipped instead of reported as errors.
+
+A local path that exists but is not a link to remote is a conflict, handled
+according to --on-conflict:
+
+  error        report it and leave it alone (default)
+  skip         leave it alone without an error
+  backup       rename it to <name>.lnkr-bak, then link
+  adopt-local  move it to remote, replacing the remote version, then link
+  overwrite    remove it, then link
+  ask          show the differences and ask for each path
+
+` + selectorHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return lnkr.CreateLinks()
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		onlyAvailable, _ := cmd.Flags().GetBool("only-available")
+		return lnkr.CreateLinks(selectorFromFlags(cmd, args), onlyAvailable, dryRun, onConflict)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(linkCmd)
+	linkCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	linkCmd.Flags().Bool("only-available", false, "Skip entries whose remote path does not exist yet")
+	addSelectorFlags(linkCmd)
+	linkCmd.Flags().String("on-conflict", lnkr.ConflictError, "How to handle existing local paths: error, skip, backup, adopt-local, overwrite or ask")
 }
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <old> <new>",
+	Short: "Rename a managed entry in remote and local",
+	Long: `Move a managed file or directory to a new path: the remote file is moved,
+the link is re-created at the new local path and the old link removed.
+The entries in .lnkr.toml (every file entry of a hard-linked directory
+included) and the git exclude section are rewritten.
+
+Everything is applied as one operation: if a step fails, the changes made
+so far are undone.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return lnkr.Move(args[0], args[1], dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+	mvCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull [path...]",
+	Short: "Copy remote changes of copy links to local",
+	Long: `Copy the remote content of entries with type "copy" or "reflink" to the
+local directory.
+
+Without paths, all copy entries are pulled. Entries already in sync are
+skipped. An entry whose local copy changed since the last sync is refused
+unless --force is given, so local changes are never overwritten by accident;
+run 'lnkr push' first to keep them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return lnkr.Pull(args, force, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+	pullCmd.Flags().BoolP("force", "f", false, "Overwrite local even if it changed since the last sync")
+	pullCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [path...]",
+	Short: "Copy local changes of copy links to remote",
+	Long: `Copy the local content of entries with type "copy" or "reflink" to the
+remote directory.
+
+Without paths, all copy entries are pushed. Entries already in sync are
+skipped. An entry whose remote content changed since the last sync is refused
+unless --force is given, so remote changes are never overwritten by accident;
+run 'lnkr pull' first to take them.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return lnkr.Push(args, force, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().BoolP("force", "f", false, "Overwrite remote even if it changed since the last sync")
+	pushCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+}
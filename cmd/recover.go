@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Complete or undo an interrupted operation",
+	Long: `Complete or undo an operation (add, remove, switch, unlink) that was
+interrupted, e.g. by Ctrl-C or a full disk.
+
+These operations record their plan and progress in .lnkr.journal before
+touching any file. Without flags, this command shows the interrupted
+operation and which of its steps took effect.
+
+  --forward    apply the remaining steps
+  --rollback   undo the steps that took effect`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		forward, _ := cmd.Flags().GetBool("forward")
+		rollback, _ := cmd.Flags().GetBool("rollback")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		var direction string
+		if forward {
+			direction = lnkr.RecoverForward
+		} else if rollback {
+			direction = lnkr.RecoverRollback
+		}
+		return lnkr.Recover(direction, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+	recoverCmd.Flags().Bool("forward", false, "Complete the interrupted operation")
+	recoverCmd.Flags().Bool("rollback", false, "Undo the interrupted operation")
+	recoverCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	recoverCmd.MarkFlagsMutuallyExclusive("forward", "rollback")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var relocateCmd = &cobra.Command{
+	Use:   "relocate --remote <path>",
+	Short: "Move the project's remote directory and re-point its links",
+	Long: `Move the whole remote directory of the project, .lnkr.toml included, to a
+new location and update remote in .lnkr.toml.
+
+Symbolic links hold the remote path, so every symbolic link and the
+.lnkr.toml symlink are pointed at the new location. Hard links keep working
+as long as the remote stays on the same filesystem; they are verified after
+the move, and a move to another filesystem is refused while hard links
+exist.
+
+A relative --remote is resolved against remote_root, like in 'lnkr init'.
+The new location must not exist or be an empty directory.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, _ := cmd.Flags().GetString("remote")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		remoteDir, err := lnkr.ExpandPath(remote)
+		if err != nil {
+			return fmt.Errorf("failed to expand remote: %w", err)
+		}
+		if !filepath.IsAbs(remoteDir) {
+			remoteRoot, err := lnkr.ExpandPath(lnkr.GetRemoteRoot())
+			if err != nil {
+				return fmt.Errorf("failed to expand remote root: %w", err)
+			}
+			remoteDir = filepath.Join(remoteRoot, remoteDir)
+		}
+		return lnkr.Relocate(remoteDir, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(relocateCmd)
+	relocateCmd.Flags().StringP("remote", "r", "", "New remote directory (relative paths are resolved against remote_root)")
+	relocateCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	_ = relocateCmd.MarkFlagRequired("remote")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Fix links with a wrong target, dangling links and diverged hard links",
+	Long: `Fix the links that 'lnkr status' reports as broken.
+
+  - Symbolic links pointing to a wrong or missing target are re-pointed to
+    the remote path.
+  - Hard links whose local file was replaced (e.g. by an editor's atomic
+    save) are linked again. The newer content is kept in remote and the
+    other copy is kept next to it as <name>.lnkr-backup-<timestamp>.
+
+Every repair is confirmed before it is applied unless --yes is given. Links
+that do not exist yet are left to 'lnkr link'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return lnkr.Repair(yes, dryRun)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+	repairCmd.Flags().BoolP("yes", "y", false, "Repair without asking for confirmation")
+	repairCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+}
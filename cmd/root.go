@@ -15,11 +15,21 @@ storage) while staying accessible from a local project directory via links.
 
 Typical workflow:
   lnkr init --remote <path>   set up the project (.lnkr.toml)
-  lnkr add <path>             move a file to remote and link it back
+  lnkr add <path...>          move files to remote and link them back
+  lnkr adopt <path>           register a file already in remote and link it
   lnkr status                 show the state of all links
+  lnkr doctor                 check the whole setup and suggest fixes
+  lnkr diff [path...]         show how local files differ from remote
   lnkr link                   re-create links (e.g. after cloning)
+  lnkr repair                 fix wrong, dangling and diverged links
+  lnkr watch                  keep hard links intact while files are edited
   lnkr unlink                 remove the links (entries and remote files kept)
+  lnkr push / lnkr pull       reconcile entries of type "copy" or "reflink"
+  lnkr mv <old> <new>         rename an entry in remote and local
   lnkr remove <path>          restore a file from remote back to local
+  lnkr relocate -r <path>     move the remote directory and re-point links
+  lnkr recover                finish or undo an interrupted operation
+  lnkr gc                     find orphaned remote files and dead entries
   lnkr clean                  remove .lnkr.toml and its git exclude entries`,
 	Version:       version.GetVersion(),
 	SilenceUsage:  true,
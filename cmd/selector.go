@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+// selectorHelp describes the entry selector shared by link, unlink, switch,
+// remove and status.
+const selectorHelp = `Entries are selected by paths and quoted glob patterns ('*', '?', '[...]'
+and '**'), absolute or relative to the current directory: a path selects the
+entry and the entries inside it. --type and --state narrow the selection and
+take comma-separated or repeated values:
+  --type   hard, sym, copy or reflink
+  --state  a link state as shown by 'lnkr status --format porcelain', or
+           broken for every state but linked and missing`
+
+// addSelectorFlags adds the --type and --state flags of the entry selector.
+func addSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("type", nil, "Select entries by link type: hard, sym, copy or reflink")
+	cmd.Flags().StringSlice("state", nil, "Select entries by state, e.g. missing or broken")
+}
+
+// selectorFromFlags returns the entry selector given by paths and the
+// selector flags.
+func selectorFromFlags(cmd *cobra.Command, paths []string) lnkr.Selector {
+	types, _ := cmd.Flags().GetStringSlice("type")
+	states, _ := cmd.Flags().GetStringSlice("state")
+	return lnkr.Selector{Paths: paths, Types: types, States: states}
+}
@@ -6,14 +6,61 @@ import (
 )
 
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [path...]",
 	Short: "Show status of links in .lnkr.toml configuration",
-	Long:  `Show the status of all links defined in the .lnkr.toml configuration file.`,
+	Long: `Show the status of the links defined in the .lnkr.toml configuration file,
+all of them or those selected by paths and selector flags (see below).
+
+Hard-linked directories are summarized in one row; with --verbose every file
+is listed below it as linked, diverged, missing-remote, missing-local or
+untracked (backups and temporary files left by lnkr).
+
+With --all, remote and the local directories holding entries are scanned
+for paths no entry covers:
+
+  remote-only      in remote, not in local
+  local-identical  in both with the same content (an unmanaged local duplicate)
+  local-different  in both with different content
+  local-only       in local next to entries, not in remote
+
+With --format, the status is written for scripts instead of as a table:
+
+  json, yaml   the roots and every entry with its state and details
+  porcelain    one line per entry: state, type and path separated by tabs
+  '{{...}}'    a Go template executed for each entry, e.g. '{{.Path}} {{.State}}'
+
+States: linked, missing, target-missing, wrong-target, not-linked,
+local-changed, remote-changed, both-changed, error.
+
+With --check, nothing is printed when all links are healthy, e.g. for login
+scripts and CI. Otherwise the unhealthy entries are printed like with
+--format porcelain and the exit code tells what is wrong:
+
+  0  all links are healthy
+  2  the configuration is missing or invalid
+  3  some links are missing, all others are healthy
+  4  some links are broken or diverged, or an operation was interrupted
+
+` + selectorHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return lnkr.Status()
+		check, _ := cmd.Flags().GetBool("check")
+		if check {
+			return lnkr.CheckStatus(selectorFromFlags(cmd, args))
+		}
+		format, _ := cmd.Flags().GetString("format")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		all, _ := cmd.Flags().GetBool("all")
+		return lnkr.Status(format, verbose, all, selectorFromFlags(cmd, args))
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().String("format", "table", "Output format: table, json, yaml, porcelain or a Go template")
+	statusCmd.Flags().BoolP("verbose", "v", false, "List every file of hard-linked directories")
+	statusCmd.Flags().BoolP("all", "a", false, "Also report paths in remote or next to entries that no entry covers")
+	statusCmd.Flags().Bool("check", false, "Print nothing when all links are healthy, exit non-zero otherwise")
+	statusCmd.MarkFlagsMutuallyExclusive("check", "format")
+	statusCmd.MarkFlagsMutuallyExclusive("check", "all")
+	addSelectorFlags(statusCmd)
 }
@@ -8,31 +8,44 @@ import (
 )
 
 var switchCmd = &cobra.Command{
-	Use:   "switch <path> [sym|hard]",
-	Short: "Switch link type for an entry",
-	Long: `Switch the link type of an existing entry between sym and hard.
+	Use:   "switch [path...] [sym|hard|copy|reflink]",
+	Short: "Switch link type for entries",
+	Long: `Switch the link type of existing entries between sym, hard, copy and
+reflink. The last argument is the new type when it names one and other paths
+or selector flags are given.
 
-If no type is specified, it toggles between sym and hard.
-Note: Directories cannot be converted to hard links.`,
-	Args: cobra.RangeArgs(1, 2),
+If no type is specified, it toggles between sym and hard (copy and reflink
+switch to sym). A copy with local changes that are not in remote is refused;
+run 'lnkr push' first. Each entry is switched in its own operation; a
+directory whose entries are all hard links is switched as a whole.
+
+` + selectorHelp,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		path := args[0]
+		sel := selectorFromFlags(cmd, args)
 		var linkType string
-		if len(args) > 1 {
-			linkType = args[1]
+		if last := len(args) - 1; last >= 0 && isLinkTypeArg(args[last]) && (last > 0 || len(sel.Types) > 0 || len(sel.States) > 0) {
+			linkType = args[last]
 			// Normalize "symbolic" to "sym" for backward compatibility
 			if linkType == "symbolic" {
 				linkType = lnkr.LinkTypeSymbolic
 			}
-			if linkType != lnkr.LinkTypeSymbolic && linkType != lnkr.LinkTypeHard {
-				return fmt.Errorf("invalid link type %q. Must be 'sym' or 'hard'", linkType)
-			}
+			sel.Paths = args[:last]
 		}
-
-		return lnkr.Switch(path, linkType)
+		if sel.IsEmpty() {
+			return fmt.Errorf("requires a path or a selector flag")
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return lnkr.Switch(sel, linkType, dryRun)
 	},
 }
 
+// isLinkTypeArg reports whether arg names a link type.
+func isLinkTypeArg(arg string) bool {
+	return lnkr.ValidLinkType(arg) || arg == "symbolic"
+}
+
 func init() {
 	rootCmd.AddCommand(switchCmd)
+	switchCmd.Flags().Bool("dry-run", false, "Show what would be done without making changes")
+	addSelectorFlags(switchCmd)
 }
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/longkey1/lnkr/internal/lnkr"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep hard links intact while files are edited",
+	Long: `Watch the local and remote paths of all entries and keep hard links intact.
+
+Editors and tools that save by writing a temporary file and renaming it over
+the original silently turn a hard link into an independent copy. When a
+hard-linked file is replaced on one side, watch takes the new file to the
+other side and links both again. Events are logged to standard output.
+
+Files that were already diverged when watch starts are reported; run
+'lnkr repair' to fix them. Restart watch after changing the entries.
+
+watch runs until interrupted or stopped with SIGTERM.
+
+  --install-systemd-user   write a systemd user unit running watch for
+                           this project, then enable it with
+                           'systemctl --user enable --now <unit>'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		install, _ := cmd.Flags().GetBool("install-systemd-user")
+		if install {
+			path, err := lnkr.InstallSystemdUserUnit()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", path)
+			fmt.Printf("Enable it with: systemctl --user daemon-reload && systemctl --user enable --now %s\n", filepath.Base(path))
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return lnkr.Watch(ctx, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().Bool("install-systemd-user", false, "Write a systemd user unit running watch for this project")
+}
@@ -2,21 +2,31 @@ package lnkr
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 )
 
-// Add adds a local file/directory to the configuration after moving it to the remote directory.
-// It then creates a link from the remote location back to the local location.
-func Add(path string, recursive bool, linkType string, dryRun bool) error {
+// Add adds local files/directories to the configuration after moving them to the remote directory.
+// It then creates links from the remote locations back to the local locations.
+// paths may hold glob patterns ("*", "?", "[...]" and "**") relative to the
+// current directory. All paths are checked before anything is moved, and
+// they are added in one operation.
+func Add(paths []string, recursive bool, linkType string, dryRun bool) error {
 	// Normalize "symbolic" to "sym" for backward compatibility
 	if linkType == "symbolic" {
 		linkType = LinkTypeSymbolic
 	}
 
-	if linkType != LinkTypeHard && linkType != LinkTypeSymbolic {
-		return fmt.Errorf("invalid link type: %s. Must be '%s' or '%s'", linkType, LinkTypeHard, LinkTypeSymbolic)
+	if !ValidLinkType(linkType) {
+		return fmt.Errorf("invalid link type: %s. Must be '%s', '%s', '%s' or '%s'", linkType, LinkTypeHard, LinkTypeSymbolic, LinkTypeCopy, LinkTypeReflink)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no paths to add")
 	}
 
 	config, err := loadConfig()
@@ -41,25 +51,15 @@ func Add(path string, recursive bool, linkType string, dryRun bool) error {
 		return fmt.Errorf("failed to expand remote path: %w", err)
 	}
 
-	// Resolve the input path (absolute or relative to the current directory)
-	// to a path relative to the local directory
-	relPath, err := resolveLocalRelPath(path, localDir)
-	if err != nil {
-		return err
+	if recursive && linkType != LinkTypeHard {
+		return fmt.Errorf("recursive option can only be used with hard links")
 	}
 
-	// Build absolute path for the local file
-	localAbs := filepath.Join(localDir, relPath)
-	fi, err := os.Stat(localAbs)
-	if os.IsNotExist(err) {
-		return fmt.Errorf("path does not exist: %s", localAbs)
-	}
+	// Resolve the input paths (absolute or relative to the current
+	// directory, or patterns) to paths relative to the local directory
+	relPaths, err := expandAddPaths(paths, localDir, config)
 	if err != nil {
-		return fmt.Errorf("failed to stat path: %w", err)
-	}
-
-	if recursive && linkType == LinkTypeSymbolic {
-		return fmt.Errorf("recursive option cannot be used with symbolic links")
+		return err
 	}
 
 	// Check existing links to avoid duplicates
@@ -68,39 +68,30 @@ func Add(path string, recursive bool, linkType string, dryRun bool) error {
 		existing[link.Path] = struct{}{}
 	}
 
+	// Check every path before moving anything
 	var targets []string
-
-	// Add paths based on type and recursive flag
-	if fi.IsDir() {
-		if linkType == LinkTypeHard && !recursive {
-			return fmt.Errorf("recursive option must be set when adding a directory with hard links")
+	var problems []error
+	for _, relPath := range relPaths {
+		found, err := addTargets(relPath, localDir, remoteDir, linkType, recursive, config, existing)
+		if err != nil {
+			problems = append(problems, err)
+			continue
 		}
-
-		if linkType == LinkTypeHard {
-			// Walk directory and add all files for hard links
-			err := filepath.Walk(localAbs, func(p string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if !info.IsDir() {
-					return addPathToTargets(p, localDir, existing, &targets)
-				}
-				return nil
-			})
-			if err != nil {
-				return fmt.Errorf("failed to walk directory: %w", err)
-			}
-		} else {
-			// Add directory itself for symbolic links
-			if err := addPathToTargets(localAbs, localDir, existing, &targets); err != nil {
-				return err
+		for _, t := range found {
+			if !slices.Contains(targets, t) {
+				targets = append(targets, t)
 			}
 		}
-	} else {
-		// Add single file
-		if err := addPathToTargets(localAbs, localDir, existing, &targets); err != nil {
-			return err
+	}
+	switch {
+	case len(problems) == 1:
+		return problems[0]
+	case len(problems) > 1:
+		msgs := make([]string, len(problems))
+		for i, p := range problems {
+			msgs[i] = p.Error()
 		}
+		return fmt.Errorf("cannot add %d path(s):\n  %s", len(problems), strings.Join(msgs, "\n  "))
 	}
 
 	if len(targets) == 0 {
@@ -108,64 +99,85 @@ func Add(path string, recursive bool, linkType string, dryRun bool) error {
 		return nil
 	}
 
-	if dryRun {
+	// Refuse entries that would make the configuration invalid, e.g. a
+	// directory containing existing entries
+	links := slices.Clone(config.Links)
+	for _, t := range targets {
+		links = append(links, Link{Path: t, Type: linkType})
+	}
+	if err := validateLinks(links); err != nil {
+		return fmt.Errorf("cannot add %s: %w", strings.Join(paths, ", "), err)
+	}
+
+	plan := planAdd(config, targets, localDir, remoteDir, linkType)
+	if isCopyType(linkType) {
+		// The moved content is what both copies start from
+		state, err := loadSyncState(config.statePath())
+		if err != nil {
+			return err
+		}
 		for _, t := range targets {
-			fmt.Printf("Would move: %s -> %s\n", filepath.Join(localDir, t), filepath.Join(remoteDir, t))
-			fmt.Printf("Would add link: %s (type: %s)\n", t, linkType)
+			hash, err := hashPath(filepath.Join(localDir, t), t, config.ignores)
+			if err != nil {
+				return fmt.Errorf("failed to hash %s: %w", t, err)
+			}
+			plan.add(recordAction(state, t, hash))
 		}
+	}
+	plan.journal(config, "add")
+	if dryRun {
+		plan.Print()
 		fmt.Printf("Dry run: %d path(s) would be added.\n", len(targets))
 		return nil
 	}
 
-	// Move files from local to remote and create links
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	for _, t := range targets {
+		fmt.Printf("Added link: %s (type: %s)\n", t, linkType)
+	}
+	return nil
+}
+
+// planAdd plans moving each target from local to remote, linking it back and
+// recording the new entries in the configuration.
+func planAdd(config *Config, targets []string, localDir, remoteDir, linkType string) *Plan {
+	plan := &Plan{}
+	links := slices.Clone(config.Links)
+	planned := make(map[string]struct{})
 	for _, t := range targets {
 		localPath := filepath.Join(localDir, t)
 		remotePath := filepath.Join(remoteDir, t)
 
 		// Create parent directory in remote if needed
 		remoteParentDir := filepath.Dir(remotePath)
-		if err := os.MkdirAll(remoteParentDir, 0755); err != nil {
-			return fmt.Errorf("failed to create remote directory %s: %w", remoteParentDir, err)
-		}
-
-		// Move the file/directory from local to remote
-		if err := os.Rename(localPath, remotePath); err != nil {
-			return fmt.Errorf("failed to move %s to %s: %w", localPath, remotePath, err)
-		}
-		fmt.Printf("Moved: %s -> %s\n", localPath, remotePath)
-
-		// Create link from remote to local
-		if err := createLink(remotePath, localPath, linkType); err != nil {
-			// Try to restore by moving back
-			if restoreErr := os.Rename(remotePath, localPath); restoreErr != nil {
-				fmt.Printf("Warning: failed to restore %s: %v\n", localPath, restoreErr)
+		if _, ok := planned[remoteParentDir]; !ok {
+			if _, err := os.Stat(remoteParentDir); os.IsNotExist(err) {
+				plan.add(Action{Kind: ActionMkdir, Entry: t, Target: remoteParentDir})
+				planned[remoteParentDir] = struct{}{}
 			}
-			return fmt.Errorf("failed to create link for %s: %w", t, err)
 		}
 
-		// Add to config
-		config.Links = append(config.Links, Link{Path: t, Type: linkType})
-		fmt.Printf("Added link: %s (type: %s)\n", t, linkType)
+		plan.add(
+			Action{Kind: ActionMove, Entry: t, Source: localPath, Target: remotePath},
+			Action{Kind: ActionLink, Entry: t, Source: remotePath, Target: localPath, LinkType: linkType, Relative: config.relativeSymlink(Link{}), CopyFallback: config.copyFallback(), Ignore: config.ignores},
+		)
+		links = append(links, Link{Path: t, Type: linkType})
 	}
 
-	sort.Slice(config.Links, func(i, j int) bool {
-		return config.Links[i].Path < config.Links[j].Path
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Path < links[j].Path
 	})
 
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
-	}
-
-	// Apply all configured link paths to GitExclude
-	if err := applyAllLinksToGitExclude(config); err != nil {
-		fmt.Printf("Warning: failed to apply link paths to GitExclude: %v\n", err)
-	}
-
-	return nil
+	cfg := configAction(config, links)
+	plan.add(cfg, excludeAction(cfg.Config, config))
+	return plan
 }
 
-// createLink creates a link from source to target
-func createLink(source, target, linkType string) error {
+// createLink creates a link from source to target. copyFallback lets reflinks
+// copy the data when the filesystem cannot clone it.
+func createLink(source, target, linkType string, copyFallback bool) error {
 	switch linkType {
 	case LinkTypeHard:
 		if err := os.Link(source, target); err != nil {
@@ -177,12 +189,35 @@ func createLink(source, target, linkType string) error {
 			return fmt.Errorf("failed to create symbolic link: %w", err)
 		}
 		fmt.Printf("Created symbolic link: %s -> %s\n", target, source)
+	case LinkTypeCopy, LinkTypeReflink:
+		return createCopy(source, target, linkType, copyFallback, "", nil)
 	default:
 		return fmt.Errorf("unknown link type: %s", linkType)
 	}
 	return nil
 }
 
+// createCopy creates target as a copy or reflink of source, leaving out the
+// paths of entry that ignore matches.
+func createCopy(source, target, linkType string, copyFallback bool, entry string, ignore *ignoreMatcher) error {
+	if linkType == LinkTypeCopy {
+		if err := copyInto(source, target, cloneNever, entry, ignore); err != nil {
+			return fmt.Errorf("failed to create copy: %w", err)
+		}
+		fmt.Printf("Copied: %s -> %s\n", source, target)
+		return nil
+	}
+	mode := cloneOnly
+	if copyFallback {
+		mode = cloneOrCopy
+	}
+	if err := copyInto(source, target, mode, entry, ignore); err != nil {
+		return fmt.Errorf("failed to create reflink: %w", err)
+	}
+	fmt.Printf("Created reflink: %s -> %s\n", target, source)
+	return nil
+}
+
 // addPathToTargets adds a single path to the targets slice if it doesn't already exist
 func addPathToTargets(absPath, baseDir string, existing map[string]struct{}, targets *[]string) error {
 	relPath, err := filepath.Rel(baseDir, absPath)
@@ -194,3 +229,200 @@ func addPathToTargets(absPath, baseDir string, existing map[string]struct{}, tar
 	}
 	return nil
 }
+
+// addTargets returns the paths relative to localDir that adding relPath
+// records: the path itself, or every file in it that is not ignored for a
+// hard-linked directory. Paths that are entries already are left out.
+func addTargets(relPath, localDir, remoteDir, linkType string, recursive bool, config *Config, existing map[string]struct{}) ([]string, error) {
+	// Build absolute path for the local file
+	localAbs := filepath.Join(localDir, relPath)
+	fi, err := os.Stat(localAbs)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("path does not exist: %s", localAbs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	// Hard links and reflinks cannot span filesystems; refuse before
+	// moving anything
+	if needsSameDevice(linkType, config) {
+		hint := "use --type sym instead"
+		if linkType == LinkTypeReflink {
+			hint = reflinkFallbackHint
+		}
+		if err := checkSameDevice(localAbs, remoteDir, linkType, hint); err != nil {
+			return nil, err
+		}
+	}
+
+	var targets []string
+
+	// Add paths based on type and recursive flag
+	if fi.IsDir() && linkType == LinkTypeHard {
+		if !recursive {
+			return nil, fmt.Errorf("recursive option must be set when adding a directory with hard links: %s", localAbs)
+		}
+
+		// Walk directory and add all files for hard links, leaving out
+		// ignored paths
+		err := filepath.Walk(localAbs, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != localAbs {
+				rel, err := filepath.Rel(localDir, p)
+				if err != nil {
+					return fmt.Errorf("failed to get relative path: %w", err)
+				}
+				if skip, err := config.ignores.walkSkip(rel, info.IsDir()); skip {
+					return err
+				}
+			}
+			if !info.IsDir() {
+				return addPathToTargets(p, localDir, existing, &targets)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk directory: %w", err)
+		}
+		return targets, nil
+	}
+
+	// Add a single file, or the directory itself for symbolic links and
+	// copies
+	if err := addPathToTargets(localAbs, localDir, existing, &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// expandAddPaths resolves the inputs of Add to paths relative to localDir,
+// in order and without duplicates. An input that does not exist but
+// contains glob characters is expanded to the matching paths, which must
+// not be empty.
+func expandAddPaths(inputs []string, localDir string, config *Config) ([]string, error) {
+	var result []string
+	appendUnique := func(rel string) {
+		if !slices.Contains(result, rel) {
+			result = append(result, rel)
+		}
+	}
+	for _, input := range inputs {
+		if _, err := os.Lstat(input); err != nil && isGlobPattern(input) {
+			matches, err := globLocalPaths(input, localDir, config)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no paths match %s", input)
+			}
+			for _, rel := range matches {
+				appendUnique(rel)
+			}
+			continue
+		}
+		rel, err := resolveLocalRelPath(input, localDir)
+		if err != nil {
+			return nil, err
+		}
+		appendUnique(rel)
+	}
+	return result, nil
+}
+
+// ReadPathList reads the paths of 'lnkr add --from-file': one per line, or
+// separated by NUL characters with null. Empty entries are skipped, as are
+// carriage returns ending lines.
+func ReadPathList(r io.Reader, null bool) ([]string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read path list: %w", err)
+	}
+	sep := "\n"
+	if null {
+		sep = "\x00"
+	}
+	var paths []string
+	for _, p := range strings.Split(string(content), sep) {
+		if !null {
+			p = strings.TrimSuffix(p, "\r")
+		}
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// isGlobPattern reports whether path contains glob characters.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globLocalPaths returns the paths relative to localDir matching pattern,
+// which is absolute or relative to the current directory. The walk starts
+// at the directory before the first segment with glob characters and skips
+// ignored paths, .git directories, files lnkr writes itself and paths that
+// are entries or inside one.
+func globLocalPaths(pattern, localDir string, config *Config) ([]string, error) {
+	abs := pattern
+	if !filepath.IsAbs(abs) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		abs = filepath.Join(cwd, pattern)
+	}
+	re, err := globRegexp(filepath.ToSlash(abs))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+
+	root := abs
+	for isGlobPattern(root) {
+		root = filepath.Dir(root)
+	}
+	if _, ok := relPathWithin(localDir, filepath.Join(root, "x")); !ok {
+		return nil, fmt.Errorf("path is outside the local directory (%s): %s", localDir, pattern)
+	}
+
+	var matches []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && p == root {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, ok := relPathWithin(localDir, p)
+		if !ok {
+			return nil
+		}
+		if d.Name() == ".git" || isLnkrInternal(d.Name()) || isManaged(rel, config.Links) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip, err := config.ignores.walkSkip(rel, d.IsDir()); skip {
+			return err
+		}
+		if re.MatchString(filepath.ToSlash(p)) {
+			matches = append(matches, rel)
+			if d.IsDir() {
+				// The directory is added as a whole
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	return matches, nil
+}
@@ -209,7 +209,7 @@ func TestAdd(t *testing.T) {
 			localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
 			writeFiles(t, localDir, tc.files)
 
-			err := Add(tc.addPath, tc.recursive, tc.linkType, false)
+			err := Add([]string{tc.addPath}, tc.recursive, tc.linkType, false)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error but got none")
@@ -251,12 +251,12 @@ func TestAddDuplicate(t *testing.T) {
 	localDir, _ := setupProject(t, &Config{Links: []Link{}})
 	writeFiles(t, localDir, map[string]string{"notes.txt": "content"})
 
-	if err := Add("notes.txt", false, LinkTypeSymbolic, false); err != nil {
+	if err := Add([]string{"notes.txt"}, false, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("unexpected error on first add: %v", err)
 	}
 
 	// Second add is a no-op because the path is already registered.
-	if err := Add("notes.txt", false, LinkTypeSymbolic, false); err != nil {
+	if err := Add([]string{"notes.txt"}, false, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("unexpected error on duplicate add: %v", err)
 	}
 
@@ -276,7 +276,7 @@ func TestAddFromSubdirectory(t *testing.T) {
 	// Paths are resolved relative to the current directory.
 	t.Chdir(filepath.Join(localDir, "conf"))
 
-	if err := Add("a.txt", false, LinkTypeSymbolic, false); err != nil {
+	if err := Add([]string{"a.txt"}, false, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -295,7 +295,7 @@ func TestAddAbsolutePathInsideLocal(t *testing.T) {
 	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
 	writeFiles(t, localDir, map[string]string{"notes.txt": "content"})
 
-	if err := Add(filepath.Join(localDir, "notes.txt"), false, LinkTypeSymbolic, false); err != nil {
+	if err := Add([]string{filepath.Join(localDir, "notes.txt")}, false, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -314,7 +314,7 @@ func TestAddDryRun(t *testing.T) {
 	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
 	writeFiles(t, localDir, map[string]string{"notes.txt": "content"})
 
-	if err := Add("notes.txt", false, LinkTypeSymbolic, true); err != nil {
+	if err := Add([]string{"notes.txt"}, false, LinkTypeSymbolic, true); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -361,9 +361,147 @@ func TestAddUnconfigured(t *testing.T) {
 				t.Fatalf("failed to save config: %v", err)
 			}
 
-			if err := Add("notes.txt", false, LinkTypeSymbolic, false); err == nil {
+			if err := Add([]string{"notes.txt"}, false, LinkTypeSymbolic, false); err == nil {
 				t.Fatalf("expected error but got none")
 			}
 		})
 	}
 }
+
+func TestAddRefusesOverlappingEntry(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a", "conf/b.txt": "b"})
+
+	if err := Add([]string{"conf/a.txt"}, false, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := Add([]string{"conf"}, false, LinkTypeSymbolic, false); err == nil {
+		t.Fatalf("expected error for a directory containing an entry, but got none")
+	}
+	if _, err := os.Lstat(filepath.Join(remoteDir, "conf", "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("refused directory was moved to remote")
+	}
+	if _, err := loadConfig(); err != nil {
+		t.Fatalf("configuration became invalid: %v", err)
+	}
+}
+
+func TestAddMultiplePaths(t *testing.T) {
+	testCases := []struct {
+		name      string
+		paths     []string
+		wantLinks []string
+	}{
+		{
+			name:      "Paths",
+			paths:     []string{".env", ".envrc", ".vscode/settings.json"},
+			wantLinks: []string{".env", ".envrc", ".vscode/settings.json"},
+		},
+		{
+			name:      "Glob",
+			paths:     []string{"config/*.local.yaml"},
+			wantLinks: []string{"config/a.local.yaml", "config/b.local.yaml"},
+		},
+		{
+			name:      "DoubleStarGlob",
+			paths:     []string{"**/.envrc"},
+			wantLinks: []string{".envrc", "sub/.envrc"},
+		},
+		{
+			name:      "GlobAndPathDeduplicated",
+			paths:     []string{".env", "config/*.yaml", "config/a.local.yaml"},
+			wantLinks: []string{".env", "config/a.local.yaml", "config/b.local.yaml", "config/base.yaml"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+			writeFiles(t, localDir, map[string]string{
+				".env":                       "env",
+				".envrc":                     "envrc",
+				".vscode/settings.json":      "{}",
+				"config/a.local.yaml":        "a",
+				"config/b.local.yaml":        "b",
+				"config/base.yaml":           "base",
+				"sub/.envrc":                 "sub",
+				"node_modules/pkg/.envrc":    "ignored",
+				"config/nested/c.local.yaml": "not matched by *",
+			})
+			t.Chdir(localDir)
+			resetGlobalConfig(t)
+			InitGlobalConfig()
+
+			if err := Add(tc.paths, false, LinkTypeSymbolic, false); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to reload config: %v", err)
+			}
+			var got []string
+			for _, link := range config.Links {
+				got = append(got, filepath.ToSlash(link.Path))
+			}
+			if !slices.Equal(got, tc.wantLinks) {
+				t.Fatalf("unexpected links: got %v, want %v", got, tc.wantLinks)
+			}
+			for _, p := range tc.wantLinks {
+				assertLink(t, filepath.Join(localDir, p), filepath.Join(remoteDir, p), LinkTypeSymbolic)
+			}
+		})
+	}
+}
+
+func TestAddValidatesAllPathsFirst(t *testing.T) {
+	testCases := []struct {
+		name  string
+		paths []string
+	}{
+		{name: "MissingPath", paths: []string{"a.txt", "missing.txt"}},
+		{name: "GlobWithoutMatches", paths: []string{"a.txt", "*.yaml"}},
+		{name: "PathOutsideLocal", paths: []string{"a.txt", "../outside.txt"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+			writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+			t.Chdir(localDir)
+
+			if err := Add(tc.paths, false, LinkTypeSymbolic, false); err == nil {
+				t.Fatal("expected error but got none")
+			}
+			if _, err := os.Lstat(filepath.Join(remoteDir, "a.txt")); !os.IsNotExist(err) {
+				t.Fatalf("a.txt was moved although another path is invalid")
+			}
+		})
+	}
+}
+
+func TestReadPathList(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		null  bool
+		want  []string
+	}{
+		{name: "Lines", input: "a.txt\nb c.txt\n\n", want: []string{"a.txt", "b c.txt"}},
+		{name: "CRLF", input: "a.txt\r\nb.txt\r\n", want: []string{"a.txt", "b.txt"}},
+		{name: "Null", input: "a.txt\x00new\nline.txt\x00", null: true, want: []string{"a.txt", "new\nline.txt"}},
+		{name: "Empty", input: "", want: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ReadPathList(strings.NewReader(tc.input), tc.null)
+			if err != nil {
+				t.Fatalf("ReadPathList failed: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("unexpected paths: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package lnkr
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Adopt records a file or directory that already exists in remote (e.g.
+// synced from another machine) as an entry and links it locally, without
+// moving anything. With all, every unmanaged path under the remote
+// directory is adopted instead of path.
+// With dryRun, the planned actions are printed instead of applied.
+func Adopt(path string, all bool, linkType string, dryRun bool) error {
+	// Normalize "symbolic" to "sym" for backward compatibility
+	if linkType == "symbolic" {
+		linkType = LinkTypeSymbolic
+	}
+	if !ValidLinkType(linkType) {
+		return fmt.Errorf("invalid link type: %s. Must be '%s', '%s', '%s' or '%s'", linkType, LinkTypeHard, LinkTypeSymbolic, LinkTypeCopy, LinkTypeReflink)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if config.Local == "" || config.Remote == "" {
+		return fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")
+	}
+
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	var targets []string
+	if all {
+		targets, err = findUnmanaged(remoteDir, config.Links, config.ignores)
+		if err != nil {
+			return err
+		}
+	} else {
+		relPath, err := resolveRemoteRelPath(path, localDir, remoteDir)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(remoteDir, relPath)); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist in remote: %s", filepath.Join(remoteDir, relPath))
+		}
+		if isManaged(relPath, config.Links) {
+			fmt.Printf("Already managed: %s\n", relPath)
+			return nil
+		}
+		targets = []string{relPath}
+	}
+
+	if len(targets) == 0 {
+		fmt.Println("No unmanaged paths to adopt.")
+		return nil
+	}
+
+	// Hard links are recorded file by file, like 'lnkr add --recursive'
+	var entries []Link
+	for _, t := range targets {
+		expanded, err := adoptEntries(t, remoteDir, linkType, config.ignores)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, expanded...)
+	}
+
+	var errorCount int
+	plan := &Plan{}
+	links := slices.Clone(config.Links)
+	for _, entry := range entries {
+		actions, err := planLinkEntry(entry, config, ConflictError, dryRun)
+		if err != nil {
+			fmt.Printf("Error: cannot adopt %s: %v\n", entry.Path, err)
+			errorCount++
+			continue
+		}
+		plan.add(actions...)
+		links = append(links, entry)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Path < links[j].Path
+	})
+	adopted := len(links) - len(config.Links)
+	if err := validateLinks(links); err != nil {
+		return fmt.Errorf("cannot adopt: %w", err)
+	}
+
+	if adopted > 0 {
+		cfg := configAction(config, links)
+		plan.add(cfg, excludeAction(cfg.Config, config))
+	}
+	plan.journal(config, "adopt")
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d path(s) would be adopted, %d error(s).\n", adopted, errorCount)
+		return nil
+	}
+
+	if adopted > 0 {
+		if err := plan.Apply(); err != nil {
+			return err
+		}
+		for _, entry := range links {
+			if !slices.Contains(config.Links, entry) {
+				fmt.Printf("Adopted: %s (type: %s)\n", entry.Path, entry.Type)
+			}
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d path(s) could not be adopted", errorCount)
+	}
+	return nil
+}
+
+// resolveRemoteRelPath converts an input path into a path relative to the
+// remote directory. Absolute paths may point into remote or local; other
+// paths are resolved like the paths of 'lnkr add', so the local path of an
+// entry that only exists in remote can be given too.
+func resolveRemoteRelPath(input, localDir, remoteDir string) (string, error) {
+	if filepath.IsAbs(input) {
+		if rel, ok := relPathWithin(remoteDir, filepath.Clean(input)); ok {
+			return rel, nil
+		}
+	}
+	rel, err := resolveLocalRelPath(input, localDir)
+	if err != nil {
+		return "", fmt.Errorf("path is outside the local and remote directories: %s", input)
+	}
+	return rel, nil
+}
+
+// adoptEntries returns the entries recording the remote path rel with
+// linkType: the path itself, or every file in it that is not ignored for
+// hard-linked directories.
+func adoptEntries(rel, remoteDir, linkType string, ignore *ignoreMatcher) ([]Link, error) {
+	remotePath := filepath.Join(remoteDir, rel)
+	info, err := os.Stat(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	if linkType != LinkTypeHard || !info.IsDir() {
+		return []Link{{Path: rel, Type: linkType}}, nil
+	}
+
+	var entries []Link
+	err = filepath.WalkDir(remotePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fileRel, err := filepath.Rel(remoteDir, p)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		if p != remotePath {
+			if skip, err := ignore.walkSkip(fileRel, d.IsDir()); skip {
+				return err
+			}
+		}
+		if d.IsDir() || isLnkrInternal(d.Name()) {
+			return nil
+		}
+		entries = append(entries, Link{Path: fileRel, Type: LinkTypeHard})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", remotePath, err)
+	}
+	return entries, nil
+}
+
+// findUnmanaged returns the paths under remoteDir that no entry covers, as
+// coarse as possible: a directory without entries in it is returned as a
+// whole. Files lnkr keeps in remote itself, .git directories and ignored
+// paths are left out.
+func findUnmanaged(remoteDir string, links []Link, ignore *ignoreMatcher) ([]string, error) {
+	var unmanaged []string
+	err := filepath.WalkDir(remoteDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == remoteDir {
+			return nil
+		}
+		rel, err := filepath.Rel(remoteDir, p)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+
+		skip := isLnkrInternal(d.Name()) || d.Name() == ".git" || isManaged(rel, links) || ignore.match(rel, d.IsDir())
+		if !skip && d.IsDir() && containsManaged(rel, links) {
+			// Look for unmanaged paths next to the entries inside
+			return nil
+		}
+		if !skip {
+			unmanaged = append(unmanaged, rel)
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", remoteDir, err)
+	}
+	return unmanaged, nil
+}
+
+// isManaged reports whether rel is an entry or inside one.
+func isManaged(rel string, links []Link) bool {
+	for _, link := range links {
+		if rel == link.Path || strings.HasPrefix(rel, link.Path+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsManaged reports whether entries exist inside the directory rel.
+func containsManaged(rel string, links []Link) bool {
+	prefix := rel + string(os.PathSeparator)
+	for _, link := range links {
+		if strings.HasPrefix(link.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLnkrInternal reports whether name is a file lnkr writes itself: the
+// configuration, journal and sync state, the archive of 'lnkr gc', and the
+// temporary, old and backup copies made while replacing files.
+func isLnkrInternal(name string) bool {
+	return name == ConfigFileName || name == JournalFileName || name == StateFileName || name == ArchiveDirName ||
+		strings.HasSuffix(name, copySuffix) || strings.HasSuffix(name, oldSuffix) ||
+		strings.HasSuffix(name, conflictBackupSuffix) || strings.Contains(name, backupMarker)
+}
@@ -0,0 +1,157 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAdopt(t *testing.T) {
+	testCases := []struct {
+		name        string
+		remoteFiles map[string]string
+		links       []Link
+		path        string // relative to the local directory unless remote is set
+		remote      bool   // give path as an absolute remote path
+		all         bool
+		linkType    string
+		wantLinks   []Link
+	}{
+		{
+			name:        "File",
+			remoteFiles: map[string]string{"a.txt": "a"},
+			path:        "a.txt",
+			linkType:    LinkTypeSymbolic,
+			wantLinks:   []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+		},
+		{
+			name:        "RemotePath",
+			remoteFiles: map[string]string{"conf/a.txt": "a"},
+			path:        "conf",
+			remote:      true,
+			linkType:    LinkTypeSymbolic,
+			wantLinks:   []Link{{Path: "conf", Type: LinkTypeSymbolic}},
+		},
+		{
+			name:        "HardDirectory",
+			remoteFiles: map[string]string{"conf/a.txt": "a", "conf/sub/b.txt": "b"},
+			path:        "conf",
+			linkType:    LinkTypeHard,
+			wantLinks: []Link{
+				{Path: "conf/a.txt", Type: LinkTypeHard},
+				{Path: "conf/sub/b.txt", Type: LinkTypeHard},
+			},
+		},
+		{
+			name:        "AlreadyManaged",
+			remoteFiles: map[string]string{"a.txt": "a"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeHard}},
+			path:        "a.txt",
+			linkType:    LinkTypeSymbolic,
+			wantLinks:   []Link{{Path: "a.txt", Type: LinkTypeHard}},
+		},
+		{
+			name: "All",
+			remoteFiles: map[string]string{
+				"a.txt":          "a",
+				"managed.txt":    "m",
+				"conf/b.txt":     "b",
+				"conf/c.txt":     "c",
+				"other/d.txt":    "d",
+				".lnkr.toml":     "",
+				"e.txt.lnkr-bak": "e",
+			},
+			links:    []Link{{Path: "managed.txt", Type: LinkTypeSymbolic}, {Path: "conf/b.txt", Type: LinkTypeSymbolic}},
+			all:      true,
+			linkType: LinkTypeSymbolic,
+			wantLinks: []Link{
+				{Path: "a.txt", Type: LinkTypeSymbolic},
+				{Path: "conf/b.txt", Type: LinkTypeSymbolic},
+				{Path: "conf/c.txt", Type: LinkTypeSymbolic},
+				{Path: "managed.txt", Type: LinkTypeSymbolic},
+				{Path: "other", Type: LinkTypeSymbolic},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: tc.links})
+			writeFiles(t, remoteDir, tc.remoteFiles)
+
+			path := tc.path
+			if tc.remote {
+				path = filepath.Join(remoteDir, tc.path)
+			}
+			if err := Adopt(path, tc.all, tc.linkType, false); err != nil {
+				t.Fatalf("Adopt failed: %v", err)
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if !slices.Equal(config.Links, tc.wantLinks) {
+				t.Fatalf("unexpected links: got %v, want %v", config.Links, tc.wantLinks)
+			}
+			for _, link := range tc.wantLinks {
+				if slices.Contains(tc.links, link) {
+					continue
+				}
+				assertLink(t, filepath.Join(localDir, link.Path), filepath.Join(remoteDir, link.Path), link.Type)
+			}
+			for name, content := range tc.remoteFiles {
+				if got := readFile(t, filepath.Join(remoteDir, name)); got != content {
+					t.Fatalf("remote file %s changed: got %q, want %q", name, got, content)
+				}
+			}
+		})
+	}
+}
+
+func TestAdoptMissingRemote(t *testing.T) {
+	setupProject(t, &Config{})
+	if err := Adopt("missing.txt", false, LinkTypeSymbolic, false); err == nil {
+		t.Fatalf("expected error for a path missing in remote, but got none")
+	}
+}
+
+func TestAdoptConflictKeepsConfig(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+	writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+
+	if err := Adopt("a.txt", false, LinkTypeSymbolic, false); err == nil {
+		t.Fatalf("expected error for a conflicting local file, but got none")
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(config.Links) != 0 {
+		t.Fatalf("conflicting path was recorded: %v", config.Links)
+	}
+	if got := readFile(t, filepath.Join(localDir, "a.txt")); got != "local" {
+		t.Fatalf("local file changed: got %q", got)
+	}
+}
+
+func TestAdoptDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+
+	if err := Adopt("", true, LinkTypeSymbolic, true); err != nil {
+		t.Fatalf("Adopt failed: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(localDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("dry run created a link")
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if len(config.Links) != 0 {
+		t.Fatalf("dry run recorded links: %v", config.Links)
+	}
+}
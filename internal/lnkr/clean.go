@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -21,19 +20,21 @@ func Clean(dryRun, assumeYes bool) error {
 		config = &Config{}
 	}
 	excludePath := config.GetGitExcludePath()
-	configPath := ConfigFileName
-	if config.dir != "" {
-		configPath = filepath.Join(config.dir, ConfigFileName)
+	configPath := config.path()
+
+	// Remove .lnkr.toml, then the LNKR section and any plain entry left by
+	// old versions
+	plan := &Plan{}
+	if configExists {
+		plan.add(Action{Kind: ActionConfig, Target: configPath, Prev: config})
 	}
+	plan.add(Action{Kind: ActionExclude, Target: excludePath, Legacy: true})
 
 	if dryRun {
-		if configExists {
-			if len(config.Links) > 0 {
-				fmt.Printf("Warning: %d link(s) are still registered in %s\n", len(config.Links), configPath)
-			}
-			fmt.Printf("Would remove %s\n", configPath)
+		if configExists && len(config.Links) > 0 {
+			fmt.Printf("Warning: %d link(s) are still registered in %s\n", len(config.Links), configPath)
 		}
-		fmt.Printf("Would remove LNKR entries from %s\n", excludePath)
+		plan.Print()
 		return nil
 	}
 
@@ -47,19 +48,8 @@ func Clean(dryRun, assumeYes bool) error {
 		}
 	}
 
-	// Remove .lnkr.toml file if it exists
-	if err := removeLnkToml(configPath); err != nil {
-		return fmt.Errorf("failed to remove %s: %w", configPath, err)
-	}
-
-	// Remove the LNKR section, and any plain entry left by old versions
-	if removed, err := removeGitExcludeSection(excludePath); err != nil {
-		return fmt.Errorf("failed to remove LNKR section from %s: %w", excludePath, err)
-	} else if removed {
-		fmt.Printf("Removed LNKR section from %s\n", excludePath)
-	}
-	if err := removeFromGitExcludeWithPath(excludePath, ConfigFileName); err != nil {
-		return fmt.Errorf("failed to remove from %s: %w", excludePath, err)
+	if err := plan.Apply(); err != nil {
+		return err
 	}
 
 	fmt.Println("Cleanup completed successfully!")
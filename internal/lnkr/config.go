@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Configuration file name constant
@@ -33,36 +34,70 @@ var ErrConfigNotFound = fmt.Errorf("%s not found in current or any parent direct
 const (
 	LinkTypeHard     = "hard"
 	LinkTypeSymbolic = "sym"
+	LinkTypeCopy     = "copy"
+	LinkTypeReflink  = "reflink"
+)
+
+// Symlink style values
+const (
+	SymlinkStyleAbsolute = "absolute"
+	SymlinkStyleRelative = "relative"
+)
+
+// Reflink fallback values
+const (
+	ReflinkFallbackError = "error"
+	ReflinkFallbackCopy  = "copy"
 )
 
 type Link struct {
 	Path string `toml:"path"`
 	Type string `toml:"type"`
+	// SymlinkStyle overrides the project's symlink_style for this entry.
+	SymlinkStyle string `toml:"symlink_style,omitempty"`
 }
 
 type Config struct {
 	Local  string `toml:"local"`
 	Remote string `toml:"remote"`
 	// LinkType determines the default link type when adding new links.
-	// Accepts "hard" or "sym" ("symbolic" is accepted as an alias).
+	// Accepts "hard", "sym", "copy" or "reflink" ("symbolic" is accepted as
+	// an alias).
 	// Defaults to "sym" if empty or invalid.
-	LinkType       string `toml:"link_type"`
+	LinkType string `toml:"link_type"`
+	// ReflinkFallback determines what reflink entries do when the
+	// filesystem cannot clone: "error" (default) or "copy".
+	ReflinkFallback string `toml:"reflink_fallback,omitempty"`
+	// SymlinkStyle determines how symbolic link targets are written:
+	// "absolute" (default) or "relative" to the link's directory, which
+	// survives moving a parent shared by local and remote.
+	SymlinkStyle   string `toml:"symlink_style,omitempty"`
 	GitExcludePath string `toml:"git_exclude_path"`
-	Links          []Link `toml:"links"`
+	// Ignore lists gitignore-syntax patterns of paths that walks over
+	// directories skip, after the global ones and before .lnkrignore.
+	Ignore []string `toml:"ignore,omitempty"`
+	Links  []Link   `toml:"links"`
 
 	// dir is the absolute path of the directory containing the loaded
 	// configuration file. Empty for configs not loaded from disk; relative
 	// paths then resolve against the current directory as before.
 	dir string
+	// ignores holds the compiled ignore patterns of a loaded configuration.
+	ignores *ignoreMatcher
 }
 
-// GetLinkType returns normalized link type value ("hard" or "sym").
+// GetLinkType returns normalized link type value ("hard", "sym", "copy" or
+// "reflink").
 // Defaults to "sym" when unset or invalid.
 // Accepts "symbolic" as an alias for "sym" for backward compatibility.
 func (c *Config) GetLinkType() string {
 	switch strings.ToLower(strings.TrimSpace(c.LinkType)) {
 	case LinkTypeHard:
 		return LinkTypeHard
+	case LinkTypeCopy:
+		return LinkTypeCopy
+	case LinkTypeReflink:
+		return LinkTypeReflink
 	case LinkTypeSymbolic, "symbolic":
 		return LinkTypeSymbolic
 	default:
@@ -134,6 +169,20 @@ func loadConfig() (*Config, error) {
 	if err := validateLinkType(config.LinkType); err != nil {
 		return nil, err
 	}
+	switch strings.ToLower(strings.TrimSpace(config.ReflinkFallback)) {
+	case "", ReflinkFallbackError, ReflinkFallbackCopy:
+	default:
+		return nil, fmt.Errorf("invalid reflink_fallback value %q in %s: expected \"error\" or \"copy\"", config.ReflinkFallback, ConfigFileName)
+	}
+	if err := validateSymlinkStyle(config.SymlinkStyle); err != nil {
+		return nil, err
+	}
+	if err := validateLinks(config.Links); err != nil {
+		return nil, err
+	}
+	if err := config.loadIgnores(); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
@@ -144,13 +193,29 @@ func LoadConfigForCLI() (*Config, error) {
 	return loadConfig()
 }
 
-func saveConfig(config *Config) error {
-	filename := ConfigFileName
-	if config.dir != "" {
-		filename = filepath.Join(config.dir, ConfigFileName)
+// path returns the path of the configuration file, anchored at the directory
+// it was loaded from when known.
+func (c *Config) path() string {
+	if c.dir != "" {
+		return filepath.Join(c.dir, ConfigFileName)
 	}
+	return ConfigFileName
+}
 
-	file, err := os.Create(filename)
+// journalPath returns the path of the journal kept next to the
+// configuration file while an operation is applied.
+func (c *Config) journalPath() string {
+	return filepath.Join(filepath.Dir(c.path()), JournalFileName)
+}
+
+// statePath returns the path of the sync state of copy entries, kept next
+// to the configuration file.
+func (c *Config) statePath() string {
+	return filepath.Join(filepath.Dir(c.path()), StateFileName)
+}
+
+func saveConfig(config *Config) error {
+	file, err := os.Create(config.path())
 	if err != nil {
 		return err
 	}
@@ -214,9 +279,120 @@ func validateLinkType(linkType string) error {
 	}
 
 	switch normalized := strings.ToLower(strings.TrimSpace(linkType)); normalized {
-	case LinkTypeHard, LinkTypeSymbolic, "symbolic":
+	case LinkTypeHard, LinkTypeSymbolic, LinkTypeCopy, LinkTypeReflink, "symbolic":
 		return nil
 	default:
-		return fmt.Errorf("invalid link_type value %q in %s: expected \"hard\" or \"sym\"", linkType, ConfigFileName)
+		return fmt.Errorf("invalid link_type value %q in %s: expected \"hard\", \"sym\", \"copy\" or \"reflink\"", linkType, ConfigFileName)
+	}
+}
+
+// ValidLinkType reports whether linkType names a link type (not counting
+// the "symbolic" alias).
+func ValidLinkType(linkType string) bool {
+	switch linkType {
+	case LinkTypeHard, LinkTypeSymbolic, LinkTypeCopy, LinkTypeReflink:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCopyType reports whether entries of linkType are independent copies of
+// remote (copy and reflink), whose sync state is tracked.
+func isCopyType(linkType string) bool {
+	return linkType == LinkTypeCopy || linkType == LinkTypeReflink
+}
+
+// copyFallback reports whether reflink entries fall back to a plain copy
+// when the filesystem cannot clone.
+func (c *Config) copyFallback() bool {
+	return strings.ToLower(strings.TrimSpace(c.ReflinkFallback)) == ReflinkFallbackCopy
+}
+
+func validateSymlinkStyle(style string) error {
+	switch strings.ToLower(strings.TrimSpace(style)) {
+	case "", SymlinkStyleAbsolute, SymlinkStyleRelative:
+		return nil
+	default:
+		return fmt.Errorf("invalid symlink_style value %q in %s: expected \"absolute\" or \"relative\"", style, ConfigFileName)
+	}
+}
+
+// validateLinks checks every [[links]] entry, so a hand-edited or synced
+// configuration cannot make lnkr touch files outside the project. All
+// problems are reported at once, each with the 1-based index of its entry.
+func validateLinks(links []Link) error {
+	var problems []string
+	report := func(i int, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("entry %d (%q): ", i+1, links[i].Path)+fmt.Sprintf(format, args...))
+	}
+
+	// paths holds the cleaned path of each entry, or "" when it is invalid
+	paths := make([]string, len(links))
+	seen := make(map[string]int)
+	folded := make(map[string]int)
+	for i, link := range links {
+		if !ValidLinkType(link.Type) {
+			report(i, "invalid type %q: expected \"hard\", \"sym\", \"copy\" or \"reflink\"", link.Type)
+		}
+		if err := validateSymlinkStyle(link.SymlinkStyle); err != nil {
+			report(i, "%v", err)
+		}
+
+		clean := filepath.Clean(link.Path)
+		switch {
+		case strings.TrimSpace(link.Path) == "":
+			report(i, "path is empty")
+			continue
+		case filepath.IsAbs(link.Path):
+			report(i, "path must be relative to the local and remote directories")
+			continue
+		case clean == ".":
+			report(i, "path must name a file or directory inside the project")
+			continue
+		case clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)):
+			report(i, "path leaves the local and remote directories")
+			continue
+		}
+
+		// Entries differing only by case or Unicode normalization name the
+		// same file on case-insensitive or normalizing filesystems
+		key := strings.ToLower(norm.NFC.String(clean))
+		if j, ok := seen[clean]; ok {
+			report(i, "duplicate of entry %d", j+1)
+		} else if j, ok := folded[key]; ok {
+			report(i, "differs from entry %d (%q) only by case or Unicode normalization", j+1, links[j].Path)
+		} else {
+			seen[clean] = i
+			folded[key] = i
+			paths[i] = clean
+		}
+	}
+
+	for i, outer := range paths {
+		if outer == "" {
+			continue
+		}
+		for j, inner := range paths {
+			if i != j && inner != "" && strings.HasPrefix(inner, outer+string(os.PathSeparator)) {
+				report(j, "inside entry %d (%q)", i+1, links[i].Path)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid [[links]] entries in %s:\n  %s", ConfigFileName, strings.Join(problems, "\n  "))
+}
+
+// relativeSymlink reports whether the symbolic link of an entry is written
+// with a target relative to its directory. The entry's own symlink_style
+// takes precedence over the project's.
+func (c *Config) relativeSymlink(link Link) bool {
+	style := link.SymlinkStyle
+	if strings.TrimSpace(style) == "" {
+		style = c.SymlinkStyle
 	}
+	return strings.ToLower(strings.TrimSpace(style)) == SymlinkStyleRelative
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -31,6 +32,16 @@ func TestLoadConfigLinkTypeValidation(t *testing.T) {
 			linkType:     "sym",
 			wantLinkType: "sym",
 		},
+		{
+			name:         "Copy",
+			linkType:     "copy",
+			wantLinkType: "copy",
+		},
+		{
+			name:         "Reflink",
+			linkType:     "reflink",
+			wantLinkType: "reflink",
+		},
 		{
 			name:         "Empty",
 			linkType:     "",
@@ -91,6 +102,32 @@ link_type = "%s"
 	}
 }
 
+func TestLoadConfigInvalidReflinkFallback(t *testing.T) {
+	setupProject(t, &Config{ReflinkFallback: "sometimes"})
+	if _, err := loadConfig(); err == nil {
+		t.Fatalf("expected error for invalid reflink_fallback, but got none")
+	}
+}
+
+func TestLoadConfigInvalidSymlinkStyle(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "Project", config: &Config{SymlinkStyle: "sideways"}},
+		{name: "Link", config: &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic, SymlinkStyle: "sideways"}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupProject(t, tc.config)
+			if _, err := loadConfig(); err == nil {
+				t.Fatalf("expected error for invalid symlink_style, but got none")
+			}
+		})
+	}
+}
+
 func TestGetDefaultRemotePath(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -212,3 +249,83 @@ func TestLoadConfigMissingFileReturnsError(t *testing.T) {
 		t.Fatalf("expected ErrConfigNotFound, got %v", err)
 	}
 }
+
+func TestValidateLinks(t *testing.T) {
+	testCases := []struct {
+		name  string
+		links []Link
+		want  []string // substrings of the error, one per problem
+	}{
+		{
+			name: "Valid",
+			links: []Link{
+				{Path: "a.txt", Type: LinkTypeSymbolic},
+				{Path: "config/", Type: LinkTypeSymbolic},
+				{Path: "configs/b.txt", Type: LinkTypeHard},
+			},
+		},
+		{
+			name:  "UnknownType",
+			links: []Link{{Path: "a.txt", Type: "softlink"}, {Path: "b.txt"}},
+			want:  []string{`entry 1 ("a.txt"): invalid type "softlink"`, `entry 2 ("b.txt"): invalid type ""`},
+		},
+		{
+			name: "PathOutsideProject",
+			links: []Link{
+				{Path: "/etc/passwd", Type: LinkTypeSymbolic},
+				{Path: "../other", Type: LinkTypeSymbolic},
+				{Path: "a/../../b", Type: LinkTypeSymbolic},
+				{Path: ".", Type: LinkTypeSymbolic},
+				{Path: "", Type: LinkTypeSymbolic},
+			},
+			want: []string{"entry 1", "entry 2", "entry 3", "entry 4", "entry 5"},
+		},
+		{
+			name:  "Duplicate",
+			links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}, {Path: "./a.txt", Type: LinkTypeHard}},
+			want:  []string{`entry 2 ("./a.txt"): duplicate of entry 1`},
+		},
+		{
+			name:  "Nested",
+			links: []Link{{Path: "config/a", Type: LinkTypeHard}, {Path: "config/", Type: LinkTypeSymbolic}},
+			want:  []string{`entry 1 ("config/a"): inside entry 2 ("config/")`},
+		},
+		{
+			name:  "CaseOnly",
+			links: []Link{{Path: "Notes.txt", Type: LinkTypeSymbolic}, {Path: "notes.txt", Type: LinkTypeSymbolic}},
+			want:  []string{`entry 2 ("notes.txt"): differs from entry 1 ("Notes.txt") only by case`},
+		},
+		{
+			name:  "NormalizationOnly",
+			links: []Link{{Path: "caf\u00e9.txt", Type: LinkTypeSymbolic}, {Path: "cafe\u0301.txt", Type: LinkTypeSymbolic}},
+			want:  []string{"entry 2", "only by case or Unicode normalization"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateLinks(tc.links)
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error, but got none")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigInvalidLinks(t *testing.T) {
+	setupProject(t, &Config{Links: []Link{{Path: "../escape", Type: LinkTypeSymbolic}}})
+	if _, err := loadConfig(); err == nil {
+		t.Fatalf("expected error for an entry outside the project, but got none")
+	}
+}
@@ -0,0 +1,150 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+)
+
+// Conflict strategies of 'lnkr link' for local paths that exist but are not
+// linked to remote
+const (
+	ConflictError      = "error"
+	ConflictSkip       = "skip"
+	ConflictBackup     = "backup"
+	ConflictAdoptLocal = "adopt-local"
+	ConflictOverwrite  = "overwrite"
+	ConflictAsk        = "ask"
+)
+
+// conflictBackupSuffix is appended to local paths moved aside by the backup
+// strategy.
+const conflictBackupSuffix = ".lnkr-bak"
+
+// oldSuffix is appended to content that is moved aside while it is
+// replaced and removed once the replacement is in place.
+const oldSuffix = ".lnkr-old"
+
+// ValidConflictStrategy reports whether strategy names a conflict strategy.
+func ValidConflictStrategy(strategy string) bool {
+	switch strategy {
+	case ConflictError, ConflictSkip, ConflictBackup, ConflictAdoptLocal, ConflictOverwrite, ConflictAsk:
+		return true
+	default:
+		return false
+	}
+}
+
+// conflictResolution is how a conflicting local path is dealt with: the
+// before actions run ahead of the link and the after actions once it
+// exists. Skipped paths are left alone.
+type conflictResolution struct {
+	before  []Action
+	after   []Action
+	skip    bool
+	adopted bool
+}
+
+// wrap returns the link actions surrounded by the resolution's actions, or
+// nothing when the path is skipped.
+func (r conflictResolution) wrap(link ...Action) []Action {
+	if r.skip {
+		return nil
+	}
+	actions := append(r.before, link...)
+	return append(actions, r.after...)
+}
+
+// resolveConflict plans dealing with the existing local path of entry
+// according to strategy. conflictErr describes the conflict and is returned
+// by the error strategy. With ask, the differences are shown and the
+// strategy is asked for; in a dry run nothing is asked and the path is
+// skipped.
+func resolveConflict(entry, localPath, remotePath, strategy string, conflictErr error, dryRun bool) (conflictResolution, error) {
+	switch strategy {
+	case ConflictSkip:
+		fmt.Printf("Skipping conflicting path: %s\n", localPath)
+		return conflictResolution{skip: true}, nil
+	case ConflictBackup:
+		backup := localPath + conflictBackupSuffix
+		if _, err := os.Lstat(backup); err == nil {
+			return conflictResolution{}, fmt.Errorf("%w (backup %s already exists)", conflictErr, backup)
+		}
+		return conflictResolution{
+			before: []Action{{Kind: ActionMove, Entry: entry, Source: localPath, Target: backup}},
+		}, nil
+	case ConflictOverwrite:
+		old := localPath + oldSuffix
+		if _, err := os.Lstat(old); err == nil {
+			return conflictResolution{}, fmt.Errorf("%w (%s already exists)", conflictErr, old)
+		}
+		return conflictResolution{
+			before: []Action{{Kind: ActionMove, Entry: entry, Source: localPath, Target: old}},
+			after:  []Action{{Kind: ActionRemove, Entry: entry, Target: old}},
+		}, nil
+	case ConflictAdoptLocal:
+		old := remotePath + oldSuffix
+		if _, err := os.Lstat(old); err == nil {
+			return conflictResolution{}, fmt.Errorf("%w (%s already exists)", conflictErr, old)
+		}
+		return conflictResolution{
+			before: []Action{
+				{Kind: ActionMove, Entry: entry, Source: remotePath, Target: old},
+				{Kind: ActionMove, Entry: entry, Source: localPath, Target: remotePath},
+			},
+			after:   []Action{{Kind: ActionRemove, Entry: entry, Target: old}},
+			adopted: true,
+		}, nil
+	case ConflictAsk:
+		fmt.Printf("Conflict: %v\n", conflictErr)
+		printConflictDiff(localPath, remotePath)
+		if dryRun {
+			fmt.Printf("Would ask how to resolve the conflict: %s\n", localPath)
+			return conflictResolution{skip: true}, nil
+		}
+		choice := choose(fmt.Sprintf("Resolve %s?", localPath), ConflictSkip, ConflictBackup, ConflictAdoptLocal, ConflictOverwrite)
+		return resolveConflict(entry, localPath, remotePath, choice, conflictErr, dryRun)
+	default:
+		return conflictResolution{}, conflictErr
+	}
+}
+
+// printConflictDiff shows how the local file differs from remote. Other
+// kinds of paths are only described.
+func printConflictDiff(localPath, remotePath string) {
+	localInfo, err := os.Lstat(localPath)
+	if err != nil {
+		return
+	}
+	remoteInfo, err := os.Stat(remotePath)
+	if err != nil {
+		return
+	}
+	if !localInfo.Mode().IsRegular() || !remoteInfo.Mode().IsRegular() {
+		fmt.Printf("Local is a %s, remote is a %s\n", fileKind(localInfo), fileKind(remoteInfo))
+		return
+	}
+	diff, err := diffFiles(remotePath, localPath)
+	if err != nil {
+		fmt.Printf("Warning: cannot compare %s: %v\n", localPath, err)
+		return
+	}
+	if diff == "" {
+		fmt.Println("Contents are identical")
+		return
+	}
+	fmt.Print(diff)
+}
+
+// fileKind names the type of file described by info.
+func fileKind(info os.FileInfo) string {
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symbolic link"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "special file"
+	}
+}
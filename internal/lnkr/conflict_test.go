@@ -0,0 +1,198 @@
+package lnkr
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateLinksOnConflict(t *testing.T) {
+	testCases := []struct {
+		name       string
+		linkType   string
+		onConflict string
+		answer     string // stdin for ask
+		wantErr    bool
+		wantLinked bool
+		wantRemote string // remote content after linking
+		wantBackup bool   // local content kept as a.txt.lnkr-bak
+	}{
+		{name: "Error", linkType: LinkTypeSymbolic, onConflict: ConflictError, wantErr: true, wantRemote: "remote"},
+		{name: "Skip", linkType: LinkTypeSymbolic, onConflict: ConflictSkip, wantRemote: "remote"},
+		{name: "Backup", linkType: LinkTypeSymbolic, onConflict: ConflictBackup, wantLinked: true, wantRemote: "remote", wantBackup: true},
+		{name: "Overwrite", linkType: LinkTypeSymbolic, onConflict: ConflictOverwrite, wantLinked: true, wantRemote: "remote"},
+		{name: "AdoptLocal", linkType: LinkTypeSymbolic, onConflict: ConflictAdoptLocal, wantLinked: true, wantRemote: "local"},
+		{name: "HardAdoptLocal", linkType: LinkTypeHard, onConflict: ConflictAdoptLocal, wantLinked: true, wantRemote: "local"},
+		{name: "CopyAdoptLocal", linkType: LinkTypeCopy, onConflict: ConflictAdoptLocal, wantLinked: true, wantRemote: "local"},
+		{name: "AskBackup", linkType: LinkTypeSymbolic, onConflict: ConflictAsk, answer: "b\n", wantLinked: true, wantRemote: "remote", wantBackup: true},
+		{name: "AskDefaultSkips", linkType: LinkTypeSymbolic, onConflict: ConflictAsk, answer: "\n", wantRemote: "remote"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: tc.linkType}}})
+			writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+			writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+			if tc.answer != "" {
+				old := stdin
+				stdin = bufio.NewReader(strings.NewReader(tc.answer))
+				t.Cleanup(func() { stdin = old })
+			}
+
+			err := CreateLinks(Selector{}, false, false, tc.onConflict)
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected error, but got none")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("CreateLinks failed: %v", err)
+			}
+
+			localPath := filepath.Join(localDir, "a.txt")
+			remotePath := filepath.Join(remoteDir, "a.txt")
+			if got := readFile(t, remotePath); got != tc.wantRemote {
+				t.Fatalf("unexpected remote content: got %q, want %q", got, tc.wantRemote)
+			}
+			if tc.wantLinked {
+				config, err := loadConfig()
+				if err != nil {
+					t.Fatalf("failed to load config: %v", err)
+				}
+				if status := checkLinkStatus(config.Links[0], config); !status.IsLink {
+					t.Fatalf("expected a.txt to be linked, got %+v", status)
+				}
+			} else if got := readFile(t, localPath); got != "local" {
+				t.Fatalf("local file was changed: got %q", got)
+			}
+
+			backup := localPath + conflictBackupSuffix
+			if tc.wantBackup {
+				if got := readFile(t, backup); got != "local" {
+					t.Fatalf("unexpected backup content: got %q", got)
+				}
+			} else if _, err := os.Lstat(backup); !os.IsNotExist(err) {
+				t.Fatalf("unexpected backup at %s", backup)
+			}
+			for _, old := range []string{localPath + oldSuffix, remotePath + oldSuffix} {
+				if _, err := os.Lstat(old); !os.IsNotExist(err) {
+					t.Fatalf("replaced content was left at %s", old)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateLinksOnConflictHardDirectory(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "conf", Type: LinkTypeHard}}})
+	writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a", "conf/b.txt": "remote"})
+	writeFiles(t, localDir, map[string]string{"conf/b.txt": "local"})
+
+	if err := CreateLinks(Selector{}, false, false, ConflictBackup); err != nil {
+		t.Fatalf("CreateLinks failed: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		assertLink(t, filepath.Join(localDir, "conf", name), filepath.Join(remoteDir, "conf", name), LinkTypeHard)
+	}
+	if got := readFile(t, filepath.Join(localDir, "conf", "b.txt"+conflictBackupSuffix)); got != "local" {
+		t.Fatalf("unexpected backup content: got %q", got)
+	}
+}
+
+func TestCreateLinksOnConflictDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+	writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+
+	for _, strategy := range []string{ConflictBackup, ConflictOverwrite, ConflictAdoptLocal, ConflictAsk} {
+		if err := CreateLinks(Selector{}, false, true, strategy); err != nil {
+			t.Fatalf("CreateLinks(Selector{}, false, %s) failed: %v", strategy, err)
+		}
+	}
+	if got := readFile(t, filepath.Join(localDir, "a.txt")); got != "local" {
+		t.Fatalf("dry run changed the local file: got %q", got)
+	}
+	if got := readFile(t, filepath.Join(remoteDir, "a.txt")); got != "remote" {
+		t.Fatalf("dry run changed the remote file: got %q", got)
+	}
+}
+
+func TestCreateLinksInvalidConflictStrategy(t *testing.T) {
+	setupProject(t, &Config{})
+	if err := CreateLinks(Selector{}, false, false, "merge"); err == nil {
+		t.Fatalf("expected error for invalid conflict strategy, but got none")
+	}
+}
+
+func TestConflictResolutionRevertedOnFailure(t *testing.T) {
+	for _, strategy := range []string{ConflictOverwrite, ConflictAdoptLocal, ConflictBackup} {
+		t.Run(strategy, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, nil)
+			writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+			writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+			localPath := filepath.Join(localDir, "a.txt")
+			remotePath := filepath.Join(remoteDir, "a.txt")
+
+			r, err := resolveConflict("a.txt", localPath, remotePath, strategy, os.ErrExist, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// A hard link to a missing file makes the link step fail after
+			// the local or remote content has been moved aside
+			plan := &Plan{}
+			plan.add(r.wrap(Action{Kind: ActionLink, Entry: "a.txt", Source: filepath.Join(remoteDir, "missing"), Target: localPath, LinkType: LinkTypeHard})...)
+
+			failed, err := plan.ApplyEach(func(string, error) {})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if failed != 1 {
+				t.Fatalf("expected 1 failed entry, got %d", failed)
+			}
+			if got := readFile(t, localPath); got != "local" {
+				t.Fatalf("local content was not restored: got %q", got)
+			}
+			if got := readFile(t, remotePath); got != "remote" {
+				t.Fatalf("remote content was not restored: got %q", got)
+			}
+			for _, aside := range []string{localPath + oldSuffix, remotePath + oldSuffix, localPath + conflictBackupSuffix} {
+				if _, err := os.Lstat(aside); !os.IsNotExist(err) {
+					t.Fatalf("content was left at %s", aside)
+				}
+			}
+		})
+	}
+}
+
+func TestConflictOldPathExists(t *testing.T) {
+	testCases := []struct {
+		name       string
+		onConflict string
+		oldPath    string // existing file in the way, relative to the temp dir
+	}{
+		{name: "Overwrite", onConflict: ConflictOverwrite, oldPath: "local/a.txt" + oldSuffix},
+		{name: "AdoptLocal", onConflict: ConflictAdoptLocal, oldPath: "remote/a.txt" + oldSuffix},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}})
+			writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+			writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+			writeFiles(t, filepath.Dir(localDir), map[string]string{tc.oldPath: "keep"})
+
+			if err := CreateLinks(Selector{}, false, false, tc.onConflict); err == nil {
+				t.Fatalf("expected error, but got none")
+			}
+			if got := readFile(t, filepath.Join(filepath.Dir(localDir), tc.oldPath)); got != "keep" {
+				t.Fatalf("existing file was changed: got %q", got)
+			}
+			if got := readFile(t, filepath.Join(localDir, "a.txt")); got != "local" {
+				t.Fatalf("local file was changed: got %q", got)
+			}
+			if got := readFile(t, filepath.Join(remoteDir, "a.txt")); got != "remote" {
+				t.Fatalf("remote file was changed: got %q", got)
+			}
+		})
+	}
+}
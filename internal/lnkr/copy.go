@@ -0,0 +1,357 @@
+package lnkr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StateFileName is the name of the file, next to the configuration file,
+// recording the content last synchronized for each copy entry. It is local
+// to the machine, like the copies themselves.
+const StateFileName = ".lnkr.state"
+
+// Sync states of copy entries, as reported by status.
+const (
+	SyncInSync        = "IN SYNC"
+	SyncLocalChanged  = "LOCAL CHANGED"
+	SyncRemoteChanged = "REMOTE CHANGED"
+	SyncBothChanged   = "BOTH CHANGED"
+)
+
+// syncState maps copy entries to the hash of the content both sides had
+// when they were last synchronized. It is the base that tells which side
+// changed since.
+type syncState struct {
+	Copies map[string]string `json:"copies"`
+
+	path string
+}
+
+// loadSyncState reads the sync state at path. A missing file is an empty
+// state.
+func loadSyncState(path string) (*syncState, error) {
+	state := &syncState{Copies: make(map[string]string), path: path}
+	content, err := os.ReadFile(state.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", StateFileName, err)
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", state.path, err)
+	}
+	if state.Copies == nil {
+		state.Copies = make(map[string]string)
+	}
+	return state, nil
+}
+
+// record sets the synced hash of entry, or forgets the entry when hash is
+// empty, and saves the state. The file is removed once no entry is left.
+func (s *syncState) record(entry, hash string) error {
+	if hash == "" {
+		delete(s.Copies, entry)
+	} else {
+		s.Copies[entry] = hash
+	}
+
+	if len(s.Copies) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", s.path, err)
+		}
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", StateFileName, err)
+	}
+	tmp := s.path + copySuffix
+	if err := os.WriteFile(tmp, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// recordAction returns an action recording hash as the synced content of
+// entry, or forgetting the entry when hash is empty.
+func recordAction(state *syncState, entry, hash string) Action {
+	return Action{Kind: ActionRecord, Entry: entry, Target: state.path, Hash: hash, PrevHash: state.Copies[entry]}
+}
+
+// syncStatus compares the local and remote content of a copy entry with the
+// content recorded at the last sync. Without a record, differing content is
+// reported as changed on both sides since neither can be trusted.
+func syncStatus(localHash, remoteHash, base string) string {
+	switch {
+	case localHash == remoteHash:
+		return SyncInSync
+	case base == "":
+		return SyncBothChanged
+	case localHash == base:
+		return SyncRemoteChanged
+	case remoteHash == base:
+		return SyncLocalChanged
+	default:
+		return SyncBothChanged
+	}
+}
+
+// copyStatus hashes both sides of a copy entry, leaving out ignored paths,
+// and returns their sync status along with the hashes.
+func copyStatus(entry, localPath, remotePath string, state *syncState, ignore *ignoreMatcher) (status, localHash, remoteHash string, err error) {
+	localHash, err = hashPath(localPath, entry, ignore)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to hash %s: %w", localPath, err)
+	}
+	remoteHash, err = hashPath(remotePath, entry, ignore)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to hash %s: %w", remotePath, err)
+	}
+	return syncStatus(localHash, remoteHash, state.Copies[entry]), localHash, remoteHash, nil
+}
+
+// checkCopyRemovable returns an error when the local copy of entry holds
+// changes that exist nowhere else, so removing it would lose them. Ignored
+// paths are left out of the sync status, so they are checked on their own.
+func checkCopyRemovable(entry, localPath, remotePath string, state *syncState, ignore *ignoreMatcher) error {
+	status, _, _, err := copyStatus(entry, localPath, remotePath, state, ignore)
+	if err != nil {
+		return err
+	}
+	if status == SyncLocalChanged || status == SyncBothChanged {
+		return fmt.Errorf("local copy has changes that are not in remote (%s): %s; run 'lnkr push' first", status, localPath)
+	}
+	kept, err := ignoredLocalOnly(entry, localPath, remotePath, ignore)
+	if err != nil {
+		return fmt.Errorf("failed to check ignored paths in %s: %w", localPath, err)
+	}
+	if len(kept) > 0 {
+		return fmt.Errorf("local copy holds ignored paths that are not in remote: %s; move them out of %s first", strings.Join(kept, ", "), localPath)
+	}
+	return nil
+}
+
+// ignoredLocalOnly returns the paths inside the local copy of entry that
+// ignore matches and that remote does not hold with the same content.
+func ignoredLocalOnly(entry, localPath, remotePath string, ignore *ignoreMatcher) ([]string, error) {
+	if ignore == nil {
+		return nil, nil
+	}
+	if info, err := os.Lstat(localPath); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	var paths []string
+	err := filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == localPath {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, p)
+		if err != nil {
+			return err
+		}
+		skip, skipErr := ignore.walkSkip(filepath.Join(entry, rel), d.IsDir())
+		if !skip {
+			return nil
+		}
+		localHash, err := hashPath(p, "", nil)
+		if err != nil {
+			return err
+		}
+		if remoteHash, err := hashPath(filepath.Join(remotePath, rel), "", nil); err != nil || remoteHash != localHash {
+			paths = append(paths, filepath.Join(entry, rel))
+		}
+		return skipErr
+	})
+	return paths, err
+}
+
+// hashPath returns the SHA-256 of a file's content, of a symlink's target,
+// or, for a directory, of the names, types and content of everything in it
+// that ignore does not match. Path holds entry, which the paths inside are
+// matched relative to.
+func hashPath(path, entry string, ignore *ignoreMatcher) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		sum, err := hashEntry(path, info)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(sum), nil
+	}
+
+	type treeEntry struct {
+		rel string
+		sum []byte
+	}
+	var entries []treeEntry
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		if p != path {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, rel), d.IsDir()); skip {
+				return err
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := hashEntry(p, info)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, treeEntry{filepath.ToSlash(rel), sum})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].rel < entries[j].rel })
+	hash := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(hash, "%s\x00%x\n", e.rel, e.sum)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashEntry hashes a single tree entry, distinguishing its type.
+func hashEntry(path string, info os.FileInfo) ([]byte, error) {
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		sum := sha256.Sum256([]byte("dir"))
+		return sum[:], nil
+	case mode&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256([]byte("symlink\x00" + link))
+		return sum[:], nil
+	case mode.IsRegular():
+		return hashFile(path)
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", path)
+	}
+}
+
+// copyInto creates target as a verified copy of source, cloning file data
+// depending on mode and leaving out the paths of entry that ignore matches.
+// Target must not exist.
+func copyInto(source, target string, mode cloneMode, entry string, ignore *ignoreMatcher) error {
+	usage, err := diskUsage(source)
+	if err != nil {
+		return fmt.Errorf("failed to measure %s: %w", source, err)
+	}
+	tmp, err := stageCopy(source, target, usage, mode, entry, ignore)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("failed to move copy into place at %s: %w", target, err)
+	}
+	return nil
+}
+
+// replaceWithCopy replaces target with a verified copy of source, cloning
+// file data depending on mode. The paths of entry that ignore matches are
+// neither copied nor replaced: those in target are kept. The old target is
+// only removed once the copy is in place.
+func replaceWithCopy(source, target string, mode cloneMode, entry string, ignore *ignoreMatcher) error {
+	usage, err := diskUsage(source)
+	if err != nil {
+		return fmt.Errorf("failed to measure %s: %w", source, err)
+	}
+	tmp, err := stageCopy(source, target, usage, mode, entry, ignore)
+	if err != nil {
+		return err
+	}
+	if err := keepIgnored(target, tmp, entry, ignore); err != nil {
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("failed to keep ignored paths of %s: %w", target, err)
+	}
+
+	old := target + oldSuffix
+	_ = os.RemoveAll(old)
+	hadTarget := pathExists(target)
+	if hadTarget {
+		if err := os.Rename(target, old); err != nil {
+			_ = os.RemoveAll(tmp)
+			return fmt.Errorf("failed to replace %s: %w", target, err)
+		}
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		if hadTarget {
+			_ = os.Rename(old, target)
+		}
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	if hadTarget {
+		if err := os.RemoveAll(old); err != nil {
+			return fmt.Errorf("failed to remove old content of %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// keepIgnored moves the paths of entry that ignore matches from the
+// directory target into its replacement tmp.
+func keepIgnored(target, tmp, entry string, ignore *ignoreMatcher) error {
+	if ignore == nil {
+		return nil
+	}
+	if info, err := os.Lstat(target); err != nil || !info.IsDir() {
+		return nil
+	}
+	if info, err := os.Lstat(tmp); err != nil || !info.IsDir() {
+		return nil
+	}
+	return filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == target {
+			return err
+		}
+		rel, err := filepath.Rel(target, p)
+		if err != nil {
+			return err
+		}
+		if !ignore.match(filepath.Join(entry, rel), d.IsDir()) {
+			return nil
+		}
+		dst := filepath.Join(tmp, rel)
+		if !pathExists(dst) {
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(p, dst); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
@@ -0,0 +1,93 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncStatus(t *testing.T) {
+	testCases := []struct {
+		name   string
+		local  string
+		remote string
+		base   string
+		want   string
+	}{
+		{name: "Same", local: "a", remote: "a", base: "x", want: SyncInSync},
+		{name: "SameWithoutBase", local: "a", remote: "a", want: SyncInSync},
+		{name: "LocalChanged", local: "b", remote: "a", base: "a", want: SyncLocalChanged},
+		{name: "RemoteChanged", local: "a", remote: "b", base: "a", want: SyncRemoteChanged},
+		{name: "BothChanged", local: "b", remote: "c", base: "a", want: SyncBothChanged},
+		{name: "DifferentWithoutBase", local: "a", remote: "b", want: SyncBothChanged},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := syncStatus(tc.local, tc.remote, tc.base); got != tc.want {
+				t.Fatalf("syncStatus() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHashPath(t *testing.T) {
+	tempDir := t.TempDir()
+	a := filepath.Join(tempDir, "a")
+	b := filepath.Join(tempDir, "b")
+	for _, dir := range []string{a, b} {
+		writeFiles(t, dir, map[string]string{"x.txt": "x", "sub/y.txt": "y"})
+	}
+
+	hash := func(path string) string {
+		t.Helper()
+		h, err := hashPath(path, "", nil)
+		if err != nil {
+			t.Fatalf("failed to hash %s: %v", path, err)
+		}
+		return h
+	}
+
+	if hash(a) != hash(b) {
+		t.Fatalf("expected identical trees to have the same hash")
+	}
+	if hash(filepath.Join(a, "x.txt")) != hash(filepath.Join(b, "x.txt")) {
+		t.Fatalf("expected identical files to have the same hash")
+	}
+
+	// Renaming a file changes the hash even though the content is the same.
+	if err := os.Rename(filepath.Join(b, "x.txt"), filepath.Join(b, "z.txt")); err != nil {
+		t.Fatalf("failed to rename: %v", err)
+	}
+	if hash(a) == hash(b) {
+		t.Fatalf("expected renamed tree to have a different hash")
+	}
+}
+
+func TestSyncStateRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+
+	state, err := loadSyncState(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading missing state: %v", err)
+	}
+	if err := state.record("a.txt", "1234"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state, err = loadSyncState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := state.Copies["a.txt"]; got != "1234" {
+		t.Fatalf("unexpected recorded hash: got %q, want %q", got, "1234")
+	}
+
+	// Forgetting the last entry removes the file.
+	if err := state.record("a.txt", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected state file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// fileID identifies a file by the device and inode it lives on. Inode
+// numbers are only unique within a filesystem, so both are needed to tell
+// whether two paths are hard links to the same file.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// getFileID returns the identity of the file described by info, or false
+// when the platform does not provide it.
+func getFileID(info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || st.Ino == 0 {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: st.Ino}, true
+}
+
+// deviceOf returns the device ID of the filesystem holding path, or of its
+// nearest existing ancestor when path does not exist yet.
+func deviceOf(path string) (uint64, error) {
+	info, err := os.Stat(nearestExisting(path))
+	if err != nil {
+		return 0, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("cannot determine device of %s", path)
+	}
+	return uint64(st.Dev), nil
+}
+
+// reflinkFallbackHint suggests how to use reflinks across filesystems.
+const reflinkFallbackHint = `set reflink_fallback = "copy" in ` + ConfigFileName + ` to copy instead`
+
+// needsSameDevice reports whether links of linkType can only be created on
+// the filesystem holding the remote file.
+func needsSameDevice(linkType string, config *Config) bool {
+	return linkType == LinkTypeHard || (linkType == LinkTypeReflink && !config.copyFallback())
+}
+
+// checkSameDevice returns an error when the directory of localPath and
+// remotePath are on different filesystems, where links of linkType cannot
+// be created. hint suggests an alternative to the user. Devices that cannot
+// be determined are not reported; creating the link reports the problem
+// instead.
+func checkSameDevice(localPath, remotePath, linkType, hint string) error {
+	localDev, err := deviceOf(filepath.Dir(localPath))
+	if err != nil {
+		return nil
+	}
+	remoteDev, err := deviceOf(remotePath)
+	if err != nil {
+		return nil
+	}
+	if localDev != remoteDev {
+		return fmt.Errorf("cannot create %s links between different filesystems (%s and %s); %s", linkTypeName(linkType), localPath, remotePath, hint)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// otherDeviceDir returns a temporary directory on a different filesystem
+// than t.TempDir(), skipping the test when none is available.
+func otherDeviceDir(t *testing.T) string {
+	t.Helper()
+
+	tempDev, err := deviceOf(t.TempDir())
+	if err != nil {
+		t.Skipf("cannot determine device of temp dir: %v", err)
+	}
+	for _, candidate := range []string{"/dev/shm", "/run/user"} {
+		dev, err := deviceOf(candidate)
+		if err != nil || dev == tempDev {
+			continue
+		}
+		dir, err := os.MkdirTemp(candidate, "lnkr-test-")
+		if err != nil {
+			continue
+		}
+		t.Cleanup(func() { _ = os.RemoveAll(dir) })
+		return dir
+	}
+	t.Skip("no writable directory on another filesystem")
+	return ""
+}
+
+func TestCompareFileIDs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "b.txt": "a"})
+	if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "a-link.txt")); err != nil {
+		t.Fatalf("failed to create hard link: %v", err)
+	}
+
+	stat := func(name string) os.FileInfo {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		return info
+	}
+
+	if msg := compareFileIDs(stat("a.txt"), stat("a-link.txt")); msg != "" {
+		t.Fatalf("expected hard links to match, got %q", msg)
+	}
+	if msg := compareFileIDs(stat("a.txt"), stat("b.txt")); msg != "different inodes" {
+		t.Fatalf("unexpected result for different files: %q", msg)
+	}
+
+	// A file on another filesystem differs even if it had the same inode.
+	other := otherDeviceDir(t)
+	writeFiles(t, other, map[string]string{"a.txt": "a"})
+	otherInfo, err := os.Stat(filepath.Join(other, "a.txt"))
+	if err != nil {
+		t.Fatalf("failed to stat: %v", err)
+	}
+	if msg := compareFileIDs(stat("a.txt"), otherInfo); msg != "different filesystems" {
+		t.Fatalf("unexpected result across filesystems: %q", msg)
+	}
+}
+
+func TestHardLinkAcrossDevicesRefused(t *testing.T) {
+	otherRemote := otherDeviceDir(t)
+	localDir, _ := setupProject(t, &Config{Links: []Link{}})
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	config.Remote = otherRemote
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	writeFiles(t, localDir, map[string]string{"hard.txt": "hard", "sym.txt": "sym"})
+
+	// add --type hard must refuse before moving anything.
+	err = Add([]string{filepath.Join(localDir, "hard.txt")}, false, LinkTypeHard, false)
+	if err == nil || !strings.Contains(err.Error(), "different filesystems") {
+		t.Fatalf("expected cross-filesystem error, got %v", err)
+	}
+	if fi, err := os.Lstat(filepath.Join(localDir, "hard.txt")); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected local file to be untouched: %v", err)
+	}
+
+	// A symbolic link works across filesystems (the move copies the file).
+	if err := Add([]string{filepath.Join(localDir, "sym.txt")}, false, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("unexpected error adding symlink: %v", err)
+	}
+	assertLink(t, filepath.Join(localDir, "sym.txt"), filepath.Join(otherRemote, "sym.txt"), LinkTypeSymbolic)
+
+	// switch to hard must refuse and keep the symbolic link.
+	err = Switch(Selector{Paths: []string{"sym.txt"}}, LinkTypeHard, false)
+	if err == nil || !strings.Contains(err.Error(), "different filesystems") {
+		t.Fatalf("expected cross-filesystem error, got %v", err)
+	}
+	assertLink(t, filepath.Join(localDir, "sym.txt"), filepath.Join(otherRemote, "sym.txt"), LinkTypeSymbolic)
+
+	// link reports the hard entry as an error instead of failing in os.Link.
+	writeFiles(t, otherRemote, map[string]string{"remote.txt": "remote"})
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	config.Links = append(config.Links, Link{Path: "remote.txt", Type: LinkTypeHard})
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(localDir, "remote.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected hard link not to be created, got %v", err)
+	}
+}
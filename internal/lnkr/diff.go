@@ -0,0 +1,487 @@
+package lnkr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table used to diff two files, so huge
+// files are summarized instead of exhausting memory.
+const maxDiffCells = 4 << 20
+
+// diffFiles returns a unified diff turning the file at aPath into the file
+// at bPath. Binary files and files too large to diff are summarized in a
+// single line. It returns "" when the contents are equal.
+func diffFiles(aPath, bPath string) (string, error) {
+	a, err := os.ReadFile(aPath)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(bPath)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(a, b) {
+		return "", nil
+	}
+	if isBinary(a) || isBinary(b) {
+		return binarySummary(aPath, bPath, a, b), nil
+	}
+	return unifiedDiff(aPath, bPath, splitLines(string(a)), splitLines(string(b))), nil
+}
+
+// binarySummary describes two differing binary files by size and hash
+// instead of diffing them.
+func binarySummary(aName, bName string, a, b []byte) string {
+	aSum, bSum := sha256.Sum256(a), sha256.Sum256(b)
+	return fmt.Sprintf("Binary files %s and %s differ (%d bytes, sha256 %x -> %d bytes, sha256 %x)\n",
+		aName, bName, len(a), aSum[:6], len(b), bSum[:6])
+}
+
+// isBinary reports whether content looks binary, i.e. has a NUL byte near
+// its start, like git does.
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// splitLines splits text into lines, keeping a missing final newline
+// visible as the last line without one.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is a line of a diff: ' ' kept, '-' removed or '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the edit script turning a into b, based on their
+// longest common subsequence. It returns nil when the inputs are too large.
+func diffLines(a, b []string) []diffOp {
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		return nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Removals go first, like in other diff tools
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// unifiedDiff formats the differences between a and b as a unified diff
+// with the given file names.
+func unifiedDiff(aName, bName string, a, b []string) string {
+	return formatUnifiedDiff(aName, bName, diffLines(a, b))
+}
+
+// formatUnifiedDiff formats the edit script ops as a unified diff with the
+// given file names. Nil ops mean the files were too large to compare.
+func formatUnifiedDiff(aName, bName string, ops []diffOp) string {
+	if ops == nil {
+		return fmt.Sprintf("Files %s and %s differ (too large to compare)\n", aName, bName)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// aLine and bLine are the 1-based line numbers before ops[k]
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	aLine[0], bLine[0] = 1, 1
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		// Extend the hunk while changes are closer than twice the context
+		start := max(k-diffContext, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = next
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[end]-aLine[start]),
+			hunkRange(bLine[start], bLine[end]-bLine[start]))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of a hunk header.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range names the line before it
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Color modes of 'lnkr diff'
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// ANSI escape sequences used to color diffs
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// fileDiff is how the local version of a file differs from remote.
+type fileDiff struct {
+	// path is relative to the local and remote directories.
+	path string
+	// text is a unified diff, or a one-line summary for binary files, files
+	// too large to compare and type mismatches.
+	text    string
+	added   int
+	removed int
+	binary  bool
+	// sizes are the remote and local sizes of binary files.
+	sizes [2]int
+}
+
+// Diff prints how the local version of each entry differs from remote, as
+// unified diffs or, with stat, as a summary of changed lines per file.
+// Identical links are skipped. paths select entries, or files and
+// directories inside entries; all entries are compared without paths.
+// color is ColorAuto (color on a terminal unless NO_COLOR is set),
+// ColorAlways or ColorNever.
+func Diff(paths []string, stat bool, color string) error {
+	switch color {
+	case ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("invalid color mode: %s. Must be '%s', '%s' or '%s'", color, ColorAuto, ColorAlways, ColorNever)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if config.Local == "" || config.Remote == "" {
+		return fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	targets, err := diffTargets(paths, localDir, config.Links)
+	if err != nil {
+		return err
+	}
+	var diffs []fileDiff
+	for _, rel := range targets {
+		d, err := diffPaths(rel, localDir, remoteDir, config.ignores)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, d...)
+	}
+
+	if stat {
+		writeDiffStat(os.Stdout, diffs)
+		return nil
+	}
+	useColor := color == ColorAlways || (color == ColorAuto && isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "")
+	for _, d := range diffs {
+		writeColoredDiff(os.Stdout, d.text, useColor)
+	}
+	return nil
+}
+
+// diffTargets returns the paths relative to localDir to compare: the paths
+// given that are entries or inside one, and the entries inside the other
+// paths given. Without paths, every entry is compared.
+func diffTargets(paths []string, localDir string, links []Link) ([]string, error) {
+	if len(paths) == 0 {
+		var targets []string
+		for _, link := range links {
+			targets = append(targets, link.Path)
+		}
+		return targets, nil
+	}
+
+	var targets []string
+	for _, p := range paths {
+		rel, err := resolveLocalRelPath(p, localDir)
+		if err != nil {
+			return nil, err
+		}
+		if isManaged(rel, links) {
+			targets = append(targets, rel)
+			continue
+		}
+		var found bool
+		for _, link := range links {
+			if isManaged(link.Path, []Link{{Path: rel}}) {
+				targets = append(targets, link.Path)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("path is not managed by lnkr: %s", p)
+		}
+	}
+	return targets, nil
+}
+
+// diffPaths compares the local and remote version of rel, recursing into
+// directories. Files that are the same (hard or symbolic links to remote)
+// or have equal content are skipped, as are files lnkr leaves behind and
+// ignored paths inside directories.
+func diffPaths(rel, localDir, remoteDir string, ignore *ignoreMatcher) ([]fileDiff, error) {
+	localPath := filepath.Join(localDir, rel)
+	remotePath := filepath.Join(remoteDir, rel)
+
+	// A symbolic link to remote is identical by definition
+	if target, err := os.Readlink(localPath); err == nil && symlinkPointsTo(localPath, target, remotePath) {
+		return nil, nil
+	}
+
+	localInfo, localErr := os.Stat(localPath)
+	remoteInfo, remoteErr := os.Stat(remotePath)
+	if localErr != nil && !os.IsNotExist(localErr) {
+		return nil, fmt.Errorf("failed to stat %s: %w", localPath, localErr)
+	}
+	if remoteErr != nil && !os.IsNotExist(remoteErr) {
+		return nil, fmt.Errorf("failed to stat %s: %w", remotePath, remoteErr)
+	}
+	if localErr != nil && remoteErr != nil {
+		return nil, nil
+	}
+	if localErr == nil && remoteErr == nil && os.SameFile(localInfo, remoteInfo) {
+		return nil, nil
+	}
+
+	localDirectory := localErr == nil && localInfo.IsDir()
+	remoteDirectory := remoteErr == nil && remoteInfo.IsDir()
+	switch {
+	case localDirectory || remoteDirectory:
+		if localErr == nil && remoteErr == nil && localDirectory != remoteDirectory {
+			return []fileDiff{{path: rel, text: fmt.Sprintf("%s is a %s in remote and a %s locally\n", rel, fileKind(remoteInfo), fileKind(localInfo))}}, nil
+		}
+		names := make(map[string]bool)
+		for _, dir := range []string{localPath, remotePath} {
+			entries, err := os.ReadDir(dir)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+			}
+			for _, e := range entries {
+				if !isLnkrInternal(e.Name()) && !isBackupPath(e.Name()) && !ignore.match(filepath.Join(rel, e.Name()), e.IsDir()) {
+					names[e.Name()] = true
+				}
+			}
+		}
+		sorted := make([]string, 0, len(names))
+		for name := range names {
+			sorted = append(sorted, name)
+		}
+		sort.Strings(sorted)
+
+		var diffs []fileDiff
+		for _, name := range sorted {
+			d, err := diffPaths(filepath.Join(rel, name), localDir, remoteDir, ignore)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, d...)
+		}
+		return diffs, nil
+	default:
+		return diffFile(rel, localPath, remotePath, localErr == nil, remoteErr == nil)
+	}
+}
+
+// diffFile compares the remote and local version of a file, either of
+// which may be missing.
+func diffFile(rel, localPath, remotePath string, localExists, remoteExists bool) ([]fileDiff, error) {
+	var local, remote []byte
+	var err error
+	remoteName, localName := "/dev/null", "/dev/null"
+	if remoteExists {
+		remoteName = remotePath
+		if remote, err = os.ReadFile(remotePath); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", remotePath, err)
+		}
+	}
+	if localExists {
+		localName = localPath
+		if local, err = os.ReadFile(localPath); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", localPath, err)
+		}
+	}
+	if remoteExists && localExists && bytes.Equal(local, remote) {
+		return nil, nil
+	}
+
+	d := fileDiff{path: rel}
+	if isBinary(local) || isBinary(remote) {
+		d.binary = true
+		d.sizes = [2]int{len(remote), len(local)}
+		d.text = binarySummary(remoteName, localName, remote, local)
+		return []fileDiff{d}, nil
+	}
+	ops := diffLines(splitLines(string(remote)), splitLines(string(local)))
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			d.added++
+		case '-':
+			d.removed++
+		}
+	}
+	d.text = formatUnifiedDiff(remoteName, localName, ops)
+	return []fileDiff{d}, nil
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeColoredDiff writes a unified diff to w, coloring headers, hunk
+// headers, removals and additions when color is set.
+func writeColoredDiff(w io.Writer, text string, color bool) {
+	if !color {
+		fmt.Fprint(w, text)
+		return
+	}
+	for _, line := range splitLines(text) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			line = ansiBold + line + ansiReset
+		case strings.HasPrefix(line, "@@"):
+			line = ansiCyan + line + ansiReset
+		case strings.HasPrefix(line, "-"):
+			line = ansiRed + line + ansiReset
+		case strings.HasPrefix(line, "+"):
+			line = ansiGreen + line + ansiReset
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// diffStatWidth is the widest bar of '+' and '-' in 'lnkr diff --stat'.
+const diffStatWidth = 40
+
+// writeDiffStat writes the changed lines per file like 'git diff --stat',
+// followed by the totals.
+func writeDiffStat(w io.Writer, diffs []fileDiff) {
+	if len(diffs) == 0 {
+		return
+	}
+	var nameWidth, maxChanges, added, removed int
+	for _, d := range diffs {
+		nameWidth = max(nameWidth, len(d.path))
+		maxChanges = max(maxChanges, d.added+d.removed)
+		added += d.added
+		removed += d.removed
+	}
+	for _, d := range diffs {
+		if d.binary {
+			fmt.Fprintf(w, " %-*s | Bin %d -> %d bytes\n", nameWidth, d.path, d.sizes[0], d.sizes[1])
+			continue
+		}
+		plus, minus := d.added, d.removed
+		if maxChanges > diffStatWidth {
+			plus = (plus*diffStatWidth + maxChanges - 1) / maxChanges
+			minus = (minus*diffStatWidth + maxChanges - 1) / maxChanges
+		}
+		fmt.Fprintf(w, " %-*s | %d %s%s\n", nameWidth, d.path, d.added+d.removed, strings.Repeat("+", plus), strings.Repeat("-", minus))
+	}
+	fmt.Fprintf(w, " %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(diffs), added, removed)
+}
@@ -0,0 +1,158 @@
+package lnkr
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "ChangedLine",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "AddedToEmpty",
+			a:    "",
+			b:    "x\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n",
+		},
+		{
+			name: "MissingNewline",
+			a:    "a\n",
+			b:    "a",
+			want: "--- a\n+++ b\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n",
+		},
+		{
+			name: "SeparateHunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unifiedDiff("a", "b", splitLines(tc.a), splitLines(tc.b))
+			if got != tc.want {
+				t.Fatalf("unexpected diff:\ngot:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFiles(t, tempDir, map[string]string{
+		"a.txt":   "same\n",
+		"b.txt":   "same\n",
+		"c.txt":   "other\n",
+		"bin.dat": "\x00\x01",
+	})
+	path := func(name string) string { return filepath.Join(tempDir, name) }
+
+	if got, err := diffFiles(path("a.txt"), path("b.txt")); err != nil || got != "" {
+		t.Fatalf("expected no diff for equal files, got %q, %v", got, err)
+	}
+	if got, err := diffFiles(path("a.txt"), path("c.txt")); err != nil || got == "" {
+		t.Fatalf("expected a diff for different files, got %q, %v", got, err)
+	}
+	want := "Binary files " + path("a.txt") + " and " + path("bin.dat") + " differ (5 bytes, sha256 a6328afc76e9 -> 2 bytes, sha256 b413f47d13ee)\n"
+	if got, err := diffFiles(path("a.txt"), path("bin.dat")); err != nil || got != want {
+		t.Fatalf("unexpected binary summary: got %q, %v", got, err)
+	}
+}
+
+func TestDiffPaths(t *testing.T) {
+	localDir, remoteDir := setupProject(t, nil)
+	writeFiles(t, remoteDir, map[string]string{
+		"conf/same.txt":    "same\n",
+		"conf/changed.txt": "a\nb\n",
+		"conf/remote.txt":  "r\n",
+		"conf/bin.dat":     "\x00remote",
+		"conf/linked.txt":  "l\n",
+		"sym.txt":          "s\n",
+	})
+	writeFiles(t, localDir, map[string]string{
+		"conf/same.txt":    "same\n",
+		"conf/changed.txt": "a\nc\n",
+		"conf/local.txt":   "x\ny\n",
+		"conf/bin.dat":     "\x00local!",
+		"conf/a.lnkr-bak":  "backup\n",
+	})
+	if err := os.Link(filepath.Join(remoteDir, "conf", "linked.txt"), filepath.Join(localDir, "conf", "linked.txt")); err != nil {
+		t.Fatalf("failed to create hard link: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(remoteDir, "sym.txt"), filepath.Join(localDir, "sym.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if diffs, err := diffPaths("sym.txt", localDir, remoteDir, nil); err != nil || len(diffs) != 0 {
+		t.Fatalf("expected no diff for a symbolic link to remote, got %v, %v", diffs, err)
+	}
+
+	diffs, err := diffPaths("conf", localDir, remoteDir, nil)
+	if err != nil {
+		t.Fatalf("diffPaths failed: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.path)
+	}
+	want := []string{"conf/bin.dat", "conf/changed.txt", "conf/local.txt", "conf/remote.txt"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected diffs: got %v, want %v", got, want)
+	}
+	if !diffs[0].binary || !strings.Contains(diffs[0].text, "7 bytes") {
+		t.Fatalf("expected a binary summary, got %q", diffs[0].text)
+	}
+	if diffs[1].added != 1 || diffs[1].removed != 1 || !strings.Contains(diffs[1].text, "-b\n+c\n") {
+		t.Fatalf("unexpected diff of changed file: %+v", diffs[1])
+	}
+	if diffs[2].added != 2 || !strings.HasPrefix(diffs[2].text, "--- /dev/null\n") {
+		t.Fatalf("unexpected diff of local-only file: %+v", diffs[2])
+	}
+	if diffs[3].removed != 1 || !strings.Contains(diffs[3].text, "+++ /dev/null\n") {
+		t.Fatalf("unexpected diff of remote-only file: %+v", diffs[3])
+	}
+
+	var stat bytes.Buffer
+	writeDiffStat(&stat, diffs)
+	wantStat := " conf/bin.dat     | Bin 7 -> 7 bytes\n" +
+		" conf/changed.txt | 2 +-\n" +
+		" conf/local.txt   | 2 ++\n" +
+		" conf/remote.txt  | 1 -\n" +
+		" 4 file(s) changed, 3 insertion(s)(+), 2 deletion(s)(-)\n"
+	if stat.String() != wantStat {
+		t.Fatalf("unexpected stat:\ngot:\n%s\nwant:\n%s", stat.String(), wantStat)
+	}
+}
+
+func TestWriteColoredDiff(t *testing.T) {
+	text := "--- a\n+++ b\n@@ -1 +1 @@\n-old\n+new\n"
+
+	var plain bytes.Buffer
+	writeColoredDiff(&plain, text, false)
+	if plain.String() != text {
+		t.Fatalf("uncolored diff changed: %q", plain.String())
+	}
+
+	var colored bytes.Buffer
+	writeColoredDiff(&colored, text, true)
+	for _, want := range []string{ansiRed + "-old" + ansiReset, ansiGreen + "+new" + ansiReset, ansiCyan + "@@ -1 +1 @@" + ansiReset} {
+		if !strings.Contains(colored.String(), want) {
+			t.Fatalf("colored diff %q does not contain %q", colored.String(), want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package lnkr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Exit codes of 'lnkr doctor'
+const (
+	DoctorExitProblems = 1 // some checks failed
+	DoctorExitConfig   = 2 // the configuration is unusable, other checks were not run
+)
+
+// doctorFinding is a problem found by a check, with the command (or edit)
+// that fixes it.
+type doctorFinding struct {
+	problem string
+	fix     string
+}
+
+// doctorCheck is the outcome of one check of 'lnkr doctor'.
+type doctorCheck struct {
+	name     string
+	findings []doctorFinding
+}
+
+// Doctor checks everything lnkr depends on: the configuration, its entries
+// and paths, the remote directory, the .lnkr.toml symlink, the git exclude
+// section and the state of every link. Each problem is
+// printed with a suggested fix. It returns an *ExitError when problems are
+// found.
+func Doctor() error {
+	checks, configOK := diagnose()
+
+	var problems int
+	for _, check := range checks {
+		if len(check.findings) == 0 {
+			fmt.Printf("OK    %s\n", check.name)
+			continue
+		}
+		fmt.Printf("FAIL  %s\n", check.name)
+		for _, f := range check.findings {
+			fmt.Printf("      - %s\n", f.problem)
+			fmt.Printf("        fix: %s\n", f.fix)
+		}
+		problems += len(check.findings)
+	}
+
+	if !configOK {
+		return &ExitError{Code: DoctorExitConfig, Err: fmt.Errorf("configuration is unusable, other checks were skipped")}
+	}
+	if problems > 0 {
+		return &ExitError{Code: DoctorExitProblems, Err: fmt.Errorf("%d problem(s) found", problems)}
+	}
+	fmt.Println("All checks passed.")
+	return nil
+}
+
+// diagnose runs the checks of 'lnkr doctor'. configOK is false when the
+// configuration could not be loaded or its paths expanded, in which case
+// only the configuration check is returned.
+func diagnose() (checks []doctorCheck, configOK bool) {
+	config, remoteDir, check := doctorConfig()
+	checks = append(checks, check)
+	if len(check.findings) > 0 {
+		return checks, false
+	}
+
+	checks = append(checks,
+		doctorJournal(config),
+		doctorRemote(remoteDir),
+		doctorConfigSymlink(config, remoteDir),
+		doctorGitExclude(config),
+		doctorLinks(config),
+	)
+	return checks, true
+}
+
+// doctorConfig loads the configuration and expands its local and remote
+// paths.
+func doctorConfig() (config *Config, remoteDir string, check doctorCheck) {
+	check.name = "configuration"
+	fail := func(problem, fix string) (*Config, string, doctorCheck) {
+		check.findings = append(check.findings, doctorFinding{problem, fix})
+		return nil, "", check
+	}
+
+	config, err := loadConfig()
+	if errors.Is(err, ErrConfigNotFound) {
+		return fail(fmt.Sprintf("%s not found", ConfigFileName), "lnkr init --remote <path>")
+	}
+	if err != nil {
+		return fail(fmt.Sprintf("cannot load %s: %v", ConfigFileName, err), fmt.Sprintf("edit %s", ConfigFileName))
+	}
+	check.name = fmt.Sprintf("configuration (%s)", config.path())
+
+	if config.Local == "" || config.Remote == "" {
+		return fail("local or remote directory not configured", "lnkr init --remote <path>")
+	}
+	if _, err := config.GetLocalExpanded(); err != nil {
+		return fail(fmt.Sprintf("cannot expand local %q: %v", config.Local, err), "set the variable or edit local in "+ConfigFileName)
+	}
+	remoteDir, err = config.GetRemoteExpanded()
+	if err != nil {
+		return fail(fmt.Sprintf("cannot expand remote %q: %v", config.Remote, err), "set the variable or edit remote in "+ConfigFileName)
+	}
+	return config, remoteDir, check
+}
+
+// doctorJournal checks that no operation was interrupted.
+func doctorJournal(config *Config) doctorCheck {
+	check := doctorCheck{name: "no interrupted operation"}
+	if _, err := os.Lstat(config.journalPath()); err == nil {
+		check.findings = append(check.findings, doctorFinding{
+			problem: fmt.Sprintf("journal of an interrupted operation found: %s", config.journalPath()),
+			fix:     "lnkr recover",
+		})
+	}
+	return check
+}
+
+// doctorRemote checks that the remote directory exists and is writable.
+func doctorRemote(remoteDir string) doctorCheck {
+	check := doctorCheck{name: fmt.Sprintf("remote directory (%s)", remoteDir)}
+	info, err := os.Stat(remoteDir)
+	switch {
+	case os.IsNotExist(err):
+		check.findings = append(check.findings, doctorFinding{"remote directory does not exist", "mkdir -p " + remoteDir})
+		return check
+	case err != nil:
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("cannot access remote directory: %v", err), "check the permissions of " + remoteDir})
+		return check
+	case !info.IsDir():
+		check.findings = append(check.findings, doctorFinding{"remote path is not a directory", "edit remote in " + ConfigFileName})
+		return check
+	}
+
+	probe, err := os.CreateTemp(remoteDir, ".lnkr-doctor-*")
+	if err != nil {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("remote directory is not writable: %v", err), "chmod u+w " + remoteDir})
+		return check
+	}
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+	return check
+}
+
+// doctorConfigSymlink checks that .lnkr.toml is a symbolic link to the copy
+// kept in remote, as set up by 'lnkr init'.
+func doctorConfigSymlink(config *Config, remoteDir string) doctorCheck {
+	check := doctorCheck{name: fmt.Sprintf("%s symlink", ConfigFileName)}
+	localPath := config.path()
+	remotePath := filepath.Join(remoteDir, ConfigFileName)
+	fix := "lnkr init --remote " + remoteDir
+
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("cannot access %s: %v", localPath, err), fix})
+		return check
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("%s is a regular file, not a link to %s", localPath, remotePath), fix})
+		return check
+	}
+	target, err := os.Readlink(localPath)
+	if err != nil {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("cannot read link %s: %v", localPath, err), fix})
+		return check
+	}
+	if !symlinkPointsTo(localPath, target, remotePath) {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("%s points to %s (expected: %s)", localPath, target, remotePath), fix})
+	}
+	return check
+}
+
+// doctorGitExclude checks that the LNKR section of the git exclude file
+// lists exactly the files lnkr keeps next to the configuration and the
+// configured entries.
+func doctorGitExclude(config *Config) doctorCheck {
+	excludePath := config.GetGitExcludePath()
+	check := doctorCheck{name: fmt.Sprintf("git exclude section (%s)", excludePath)}
+	const fix = "lnkr link"
+
+	content, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("cannot read %s: %v", excludePath, err), "check the permissions of " + excludePath})
+		return check
+	}
+	lines := strings.Split(string(content), "\n")
+	start, end := findGitExcludeSection(lines)
+	if start == -1 {
+		check.findings = append(check.findings, doctorFinding{"LNKR section not found", fix})
+		return check
+	}
+
+	actual := make(map[string]bool)
+	for _, line := range lines[start+1 : end] {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		actual["/"+strings.TrimPrefix(line, "/")] = true
+	}
+	expected := make(map[string]bool)
+	for _, entry := range []string{ConfigFileName, JournalFileName, StateFileName} {
+		expected["/"+entry] = true
+	}
+	for _, link := range config.Links {
+		expected["/"+link.Path] = true
+	}
+
+	for _, entry := range sortedKeys(expected) {
+		if !actual[entry] {
+			check.findings = append(check.findings, doctorFinding{fmt.Sprintf("missing entry: %s", entry), fix})
+		}
+	}
+	for _, entry := range sortedKeys(actual) {
+		if !expected[entry] {
+			check.findings = append(check.findings, doctorFinding{fmt.Sprintf("entry without a link: %s", entry), fix})
+		}
+	}
+	return check
+}
+
+// sortedKeys returns the keys of set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// doctorLinks checks the state of every link, like 'lnkr status'.
+func doctorLinks(config *Config) doctorCheck {
+	check := doctorCheck{name: fmt.Sprintf("links (%d)", len(config.Links))}
+	for _, link := range config.Links {
+		status := checkLinkStatus(link, config)
+		var fix string
+		switch status.State {
+		case StateLinked:
+			continue
+		case StateMissing:
+			fix = "lnkr link"
+		case StateTargetMissing:
+			// Usually a cloud sync that has not caught up yet, not a dead entry
+			fix = "wait for remote to sync, or lnkr link --only-available (lnkr gc --prune if it was deleted on purpose)"
+		case StateLocalChanged:
+			fix = "lnkr push"
+		case StateRemoteChanged:
+			fix = "lnkr pull"
+		case StateBothChanged:
+			fix = "lnkr push --force or lnkr pull --force"
+		default:
+			fix = "lnkr repair"
+		}
+		check.findings = append(check.findings, doctorFinding{fmt.Sprintf("%s: %s", link.Path, getStatusText(status)), fix})
+	}
+	return check
+}
@@ -0,0 +1,194 @@
+package lnkr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupHealthyProject sets up a project as 'lnkr init' and 'lnkr add' leave
+// it: .lnkr.toml linked into remote, the git exclude section written and
+// the entries linked.
+func setupHealthyProject(t *testing.T, links []Link, remoteFiles map[string]string) (localDir, remoteDir string) {
+	t.Helper()
+
+	localDir, remoteDir = setupProject(t, &Config{Links: links})
+	writeFiles(t, remoteDir, remoteFiles)
+	remoteConfig := filepath.Join(remoteDir, ConfigFileName)
+	if err := os.Rename(ConfigFileName, remoteConfig); err != nil {
+		t.Fatalf("failed to move config: %v", err)
+	}
+	if err := os.Symlink(remoteConfig, ConfigFileName); err != nil {
+		t.Fatalf("failed to link config: %v", err)
+	}
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("CreateLinks failed: %v", err)
+	}
+	return localDir, remoteDir
+}
+
+// failingChecks returns the names of the checks with findings, without the
+// details in parentheses.
+func failingChecks(checks []doctorCheck) []string {
+	var names []string
+	for _, check := range checks {
+		if len(check.findings) > 0 {
+			name, _, _ := strings.Cut(check.name, " (")
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestDiagnose(t *testing.T) {
+	links := []Link{{Path: "a.txt", Type: LinkTypeSymbolic}, {Path: "conf", Type: LinkTypeSymbolic}}
+	remoteFiles := map[string]string{"a.txt": "a", "conf/b.txt": "b"}
+
+	testCases := []struct {
+		name    string
+		mutate  func(t *testing.T, localDir, remoteDir string)
+		wantBad []string
+	}{
+		{
+			name:   "Healthy",
+			mutate: func(t *testing.T, localDir, remoteDir string) {},
+		},
+		{
+			name: "ConfigNotLinked",
+			mutate: func(t *testing.T, localDir, remoteDir string) {
+				content := readFile(t, ConfigFileName)
+				if err := os.Remove(ConfigFileName); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(ConfigFileName, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBad: []string{".lnkr.toml symlink"},
+		},
+		{
+			name: "StaleExcludeSection",
+			mutate: func(t *testing.T, localDir, remoteDir string) {
+				config := &Config{Local: localDir, Remote: remoteDir, Links: []Link{{Path: "old.txt", Type: LinkTypeSymbolic}}}
+				if err := applyAllLinksToGitExclude(config); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBad: []string{"git exclude section"},
+		},
+		{
+			name: "BrokenLink",
+			mutate: func(t *testing.T, localDir, remoteDir string) {
+				if err := os.Remove(filepath.Join(localDir, "a.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBad: []string{"links"},
+		},
+		{
+			name: "InterruptedOperation",
+			mutate: func(t *testing.T, localDir, remoteDir string) {
+				if err := os.WriteFile(JournalFileName, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBad: []string{"no interrupted operation"},
+		},
+		{
+			name: "RemoteMissing",
+			mutate: func(t *testing.T, localDir, remoteDir string) {
+				config, err := loadConfig()
+				if err != nil {
+					t.Fatal(err)
+				}
+				config.Remote = filepath.Join(remoteDir, "missing")
+				if err := saveConfig(config); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantBad: []string{"remote directory", ".lnkr.toml symlink", "links"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupHealthyProject(t, links, remoteFiles)
+			tc.mutate(t, localDir, remoteDir)
+
+			checks, configOK := diagnose()
+			if !configOK {
+				t.Fatalf("configuration reported unusable: %v", checks)
+			}
+			if got := failingChecks(checks); !slices.Equal(got, tc.wantBad) {
+				t.Fatalf("unexpected failing checks: got %v, want %v", got, tc.wantBad)
+			}
+			for _, check := range checks {
+				for _, f := range check.findings {
+					if f.fix == "" {
+						t.Fatalf("finding without a fix: %s", f.problem)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDoctorExitCodes(t *testing.T) {
+	t.Run("Healthy", func(t *testing.T) {
+		setupHealthyProject(t, []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}, map[string]string{"a.txt": "a"})
+		if err := Doctor(); err != nil {
+			t.Fatalf("Doctor failed: %v", err)
+		}
+	})
+
+	t.Run("Problems", func(t *testing.T) {
+		localDir, _ := setupHealthyProject(t, []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}, map[string]string{"a.txt": "a"})
+		if err := os.Remove(filepath.Join(localDir, "a.txt")); err != nil {
+			t.Fatal(err)
+		}
+		var exitErr *ExitError
+		if err := Doctor(); !errors.As(err, &exitErr) || exitErr.Code != DoctorExitProblems {
+			t.Fatalf("expected exit code %d, got %v", DoctorExitProblems, err)
+		}
+	})
+
+	t.Run("UnusableConfig", func(t *testing.T) {
+		t.Setenv("LNKR_DOCTOR_TEST_UNSET", "")
+		setupProject(t, &Config{})
+		config, err := loadConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Remote = "$LNKR_DOCTOR_TEST_UNSET/remote"
+		if err := saveConfig(config); err != nil {
+			t.Fatal(err)
+		}
+		var exitErr *ExitError
+		if err := Doctor(); !errors.As(err, &exitErr) || exitErr.Code != DoctorExitConfig {
+			t.Fatalf("expected exit code %d, got %v", DoctorExitConfig, err)
+		}
+	})
+}
+
+func TestDoctorLinksTargetMissing(t *testing.T) {
+	_, remoteDir := setupHealthyProject(t, []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}, map[string]string{"a.txt": "a"})
+	if err := os.Remove(filepath.Join(remoteDir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := doctorLinks(config)
+	if len(check.findings) != 1 {
+		t.Fatalf("unexpected findings: %v", check.findings)
+	}
+	// Pruning is not the first thing to suggest for a remote still syncing
+	if fix := check.findings[0].fix; !strings.HasPrefix(fix, "wait for remote to sync") || !strings.Contains(fix, "lnkr link --only-available") {
+		t.Fatalf("unexpected fix: %q", fix)
+	}
+}
@@ -0,0 +1,16 @@
+package lnkr
+
+// ExitError is returned by commands whose outcome is reported through a
+// specific process exit code, e.g. 'lnkr doctor' finding problems.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
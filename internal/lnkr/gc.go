@@ -0,0 +1,132 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveDirName is the directory in remote that 'lnkr gc --archive' moves
+// orphaned files to, in a subdirectory per run.
+const ArchiveDirName = ".lnkr-archive"
+
+// What GC does with orphaned remote paths
+const (
+	OrphansReport  = ""
+	OrphansArchive = "archive"
+	OrphansDelete  = "delete"
+)
+
+// GC reports paths in remote that no entry covers (orphans) and entries
+// whose remote path is gone (dead entries). Orphans are archived or
+// deleted depending on orphans (OrphansArchive, OrphansDelete), and dead
+// entries are dropped from the configuration when prune is set. Changes
+// are confirmed unless assumeYes is set. With dryRun, the planned actions
+// are printed instead of applied.
+func GC(orphans string, prune, assumeYes, dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if config.Local == "" || config.Remote == "" {
+		return fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	orphaned, err := findUnmanaged(remoteDir, config.Links, config.ignores)
+	if err != nil {
+		return err
+	}
+	var dead []Link
+	var live []Link
+	for _, link := range config.Links {
+		if _, err := os.Lstat(filepath.Join(remoteDir, link.Path)); os.IsNotExist(err) {
+			dead = append(dead, link)
+		} else {
+			live = append(live, link)
+		}
+	}
+
+	if len(orphaned) == 0 && len(dead) == 0 {
+		fmt.Println("Nothing to clean up.")
+		return nil
+	}
+	for _, p := range orphaned {
+		fmt.Printf("Orphan: %s\n", filepath.Join(remoteDir, p))
+	}
+	for _, link := range dead {
+		fmt.Printf("Dead entry: %s (remote path is gone)\n", link.Path)
+	}
+
+	plan := &Plan{}
+	switch orphans {
+	case OrphansArchive:
+		archiveDir := filepath.Join(remoteDir, ArchiveDirName, time.Now().Format(backupTimeFormat))
+		created := make(map[string]bool)
+		for _, p := range orphaned {
+			target := filepath.Join(archiveDir, p)
+			if dir := filepath.Dir(target); !created[dir] {
+				plan.add(Action{Kind: ActionMkdir, Entry: p, Target: dir})
+				created[dir] = true
+			}
+			plan.add(Action{Kind: ActionMove, Entry: p, Source: filepath.Join(remoteDir, p), Target: target})
+		}
+	case OrphansDelete:
+		for _, p := range orphaned {
+			plan.add(Action{Kind: ActionRemove, Entry: p, Target: filepath.Join(remoteDir, p)})
+		}
+	case OrphansReport:
+	default:
+		return fmt.Errorf("invalid orphan action: %s. Must be '%s' or '%s'", orphans, OrphansArchive, OrphansDelete)
+	}
+	if prune && len(dead) > 0 {
+		cfg := configAction(config, live)
+		plan.add(cfg, excludeAction(cfg.Config, config))
+	}
+
+	if len(plan.Actions) == 0 {
+		fmt.Printf("Found %d orphan(s) and %d dead entr(ies). Use --archive or --delete for orphans and --prune for dead entries.\n", len(orphaned), len(dead))
+		return nil
+	}
+
+	if dryRun {
+		plan.Print()
+		return nil
+	}
+
+	if !assumeYes && !confirm(gcPrompt(orphans, len(orphaned), prune, len(dead))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	plan.journal(config, "gc")
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	fmt.Println("Clean up completed.")
+	return nil
+}
+
+// gcPrompt returns the confirmation question for the changes GC makes.
+func gcPrompt(orphans string, orphanCount int, prune bool, deadCount int) string {
+	var prompt string
+	switch orphans {
+	case OrphansArchive:
+		prompt = fmt.Sprintf("Archive %d orphan(s) to %s", orphanCount, ArchiveDirName)
+	case OrphansDelete:
+		prompt = fmt.Sprintf("Delete %d orphan(s)", orphanCount)
+	}
+	if prune && deadCount > 0 {
+		if prompt != "" {
+			prompt += " and drop"
+		} else {
+			prompt = "Drop"
+		}
+		prompt += fmt.Sprintf(" %d dead entr(ies)", deadCount)
+	}
+	return prompt + "?"
+}
@@ -0,0 +1,122 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGC(t *testing.T) {
+	links := []Link{
+		{Path: "kept.txt", Type: LinkTypeSymbolic},
+		{Path: "conf/a.txt", Type: LinkTypeHard},
+		{Path: "gone.txt", Type: LinkTypeSymbolic},
+	}
+	remoteFiles := map[string]string{
+		"kept.txt":    "k",
+		"conf/a.txt":  "a",
+		"conf/b.txt":  "b",
+		"stale/c.txt": "c",
+	}
+
+	testCases := []struct {
+		name        string
+		orphans     string
+		prune       bool
+		wantRemoved bool // orphans are gone from their place in remote
+		wantArchive bool // orphans are in the archive directory
+		wantLinks   []Link
+	}{
+		{
+			name:      "Report",
+			orphans:   OrphansReport,
+			wantLinks: links,
+		},
+		{
+			name:        "Archive",
+			orphans:     OrphansArchive,
+			wantRemoved: true,
+			wantArchive: true,
+			wantLinks:   links,
+		},
+		{
+			name:        "Delete",
+			orphans:     OrphansDelete,
+			wantRemoved: true,
+			wantLinks:   links,
+		},
+		{
+			name:      "Prune",
+			orphans:   OrphansReport,
+			prune:     true,
+			wantLinks: links[:2],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, remoteDir := setupProject(t, &Config{Links: links})
+			writeFiles(t, remoteDir, remoteFiles)
+
+			if err := GC(tc.orphans, tc.prune, true, false); err != nil {
+				t.Fatalf("GC failed: %v", err)
+			}
+
+			for _, orphan := range []string{"conf/b.txt", "stale"} {
+				_, err := os.Lstat(filepath.Join(remoteDir, orphan))
+				if removed := os.IsNotExist(err); removed != tc.wantRemoved {
+					t.Fatalf("orphan %s removed: got %v, want %v", orphan, removed, tc.wantRemoved)
+				}
+			}
+			archived, _ := filepath.Glob(filepath.Join(remoteDir, ArchiveDirName, "*", "stale", "c.txt"))
+			if (len(archived) == 1) != tc.wantArchive {
+				t.Fatalf("orphan archived: got %v, want %v", archived, tc.wantArchive)
+			}
+			for _, name := range []string{"kept.txt", "conf/a.txt"} {
+				if got := readFile(t, filepath.Join(remoteDir, name)); got != remoteFiles[name] {
+					t.Fatalf("managed file %s changed: got %q", name, got)
+				}
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if !slices.Equal(config.Links, tc.wantLinks) {
+				t.Fatalf("unexpected links: got %v, want %v", config.Links, tc.wantLinks)
+			}
+
+			// The archive is not reported as an orphan by the next run
+			orphaned, err := findUnmanaged(remoteDir, config.Links, nil)
+			if err != nil {
+				t.Fatalf("findUnmanaged failed: %v", err)
+			}
+			for _, p := range orphaned {
+				if p == ArchiveDirName {
+					t.Fatalf("archive directory reported as orphan")
+				}
+			}
+		})
+	}
+}
+
+func TestGCDryRun(t *testing.T) {
+	links := []Link{{Path: "gone.txt", Type: LinkTypeSymbolic}}
+	_, remoteDir := setupProject(t, &Config{Links: links})
+	writeFiles(t, remoteDir, map[string]string{"orphan.txt": "o"})
+
+	if err := GC(OrphansDelete, true, true, true); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(remoteDir, "orphan.txt")); got != "o" {
+		t.Fatalf("dry run changed orphan: got %q", got)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if !slices.Equal(config.Links, links) {
+		t.Fatalf("dry run changed links: %v", config.Links)
+	}
+}
@@ -13,6 +13,7 @@ const (
 	ConfigKeyLocalRoot      = "local_root"
 	ConfigKeyLinkType       = "link_type"
 	ConfigKeyGitExcludePath = "git_exclude_path"
+	ConfigKeyIgnore         = "ignore"
 )
 
 // InitGlobalConfig initializes viper with global configuration settings.
@@ -33,6 +34,7 @@ func InitGlobalConfig() {
 	// local_root has no default - when empty, uses current directory name only
 	viper.SetDefault(ConfigKeyLinkType, LinkTypeSymbolic)
 	viper.SetDefault(ConfigKeyGitExcludePath, GitExcludePath)
+	viper.SetDefault(ConfigKeyIgnore, DefaultIgnorePatterns)
 
 	// Enable environment variable binding
 	// LNKR_REMOTE_ROOT -> remote_root
@@ -45,6 +47,7 @@ func InitGlobalConfig() {
 	_ = viper.BindEnv(ConfigKeyLocalRoot, "LNKR_LOCAL_ROOT")
 	_ = viper.BindEnv(ConfigKeyLinkType, "LNKR_LINK_TYPE")
 	_ = viper.BindEnv(ConfigKeyGitExcludePath, "LNKR_GIT_EXCLUDE_PATH")
+	_ = viper.BindEnv(ConfigKeyIgnore, "LNKR_IGNORE")
 
 	// Read config file (ignore error if not found)
 	_ = viper.ReadInConfig()
@@ -73,3 +76,10 @@ func GetGlobalLinkType() string {
 func GetGlobalGitExcludePath() string {
 	return viper.GetString(ConfigKeyGitExcludePath)
 }
+
+// GetGlobalIgnore returns the ignore patterns applied before those of the
+// project. LNKR_IGNORE separates patterns with spaces.
+// Priority: environment variable > config file > default value
+func GetGlobalIgnore() []string {
+	return viper.GetStringSlice(ConfigKeyIgnore)
+}
@@ -3,6 +3,7 @@ package lnkr
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -19,7 +20,7 @@ func resetGlobalConfig(t *testing.T) {
 	// Point HOME at an empty directory so the user's real global config
 	// file is never read, and clear LNKR variables from the environment.
 	t.Setenv("HOME", t.TempDir())
-	for _, key := range []string{"LNKR_REMOTE_ROOT", "LNKR_LOCAL_ROOT", "LNKR_LINK_TYPE", "LNKR_GIT_EXCLUDE_PATH"} {
+	for _, key := range []string{"LNKR_REMOTE_ROOT", "LNKR_LOCAL_ROOT", "LNKR_LINK_TYPE", "LNKR_GIT_EXCLUDE_PATH", "LNKR_IGNORE"} {
 		t.Setenv(key, "")
 	}
 }
@@ -42,6 +43,9 @@ func TestGlobalConfigDefaults(t *testing.T) {
 	if got := GetGlobalGitExcludePath(); got != GitExcludePath {
 		t.Fatalf("unexpected git exclude path: got %q, want %q", got, GitExcludePath)
 	}
+	if got := GetGlobalIgnore(); !slices.Equal(got, DefaultIgnorePatterns) {
+		t.Fatalf("unexpected ignore patterns: got %q, want %q", got, DefaultIgnorePatterns)
+	}
 }
 
 func TestGlobalConfigFromFile(t *testing.T) {
@@ -0,0 +1,255 @@
+package lnkr
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the optional file next to the configuration listing
+// ignore patterns, one per line.
+const IgnoreFileName = ".lnkrignore"
+
+// DefaultIgnorePatterns are ignored when the global configuration does not
+// set ignore.
+var DefaultIgnorePatterns = []string{".DS_Store", "*.swp", "__pycache__/", "node_modules/"}
+
+// ignoreRule is one gitignore-syntax pattern compiled to a regular
+// expression matching slash-separated paths relative to the local or
+// remote directory.
+type ignoreRule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher decides which paths the walks over directories skip. Like
+// in gitignore, the last matching pattern wins and a pattern starting with
+// "!" includes a path again. A nil matcher ignores nothing.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// newIgnoreMatcher compiles patterns in order. Blank lines and lines
+// starting with "#" are skipped.
+func newIgnoreMatcher(patterns []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	for _, p := range patterns {
+		rule, ok, err := parseIgnorePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return m, nil
+}
+
+// parseIgnorePattern compiles one pattern. ok is false for blank lines and
+// comments.
+func parseIgnorePattern(pattern string) (rule ignoreRule, ok bool, err error) {
+	p := strings.TrimRight(pattern, " \t")
+	if strings.HasSuffix(p, "\\") && strings.HasSuffix(pattern, " ") {
+		// An escaped trailing space is kept
+		p += " "
+	}
+	if p == "" || strings.HasPrefix(p, "#") {
+		return ignoreRule{}, false, nil
+	}
+	rule.pattern = p
+
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	} else if strings.HasPrefix(p, "\\!") || strings.HasPrefix(p, "\\#") {
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return ignoreRule{}, false, fmt.Errorf("invalid ignore pattern %q", pattern)
+	}
+
+	// Patterns without a slash match at any depth, others are anchored at
+	// the local or remote directory
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	if !strings.Contains(p, "/") {
+		segments = append([]string{"**"}, segments...)
+	}
+
+	rule.re, err = globRegexp(strings.Join(segments, "/"))
+	if err != nil {
+		return ignoreRule{}, false, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+	}
+	return rule, true, nil
+}
+
+// globRegexp compiles a slash-separated glob pattern to a regular
+// expression matching whole paths. A "**" segment matches any number of
+// directories, or everything inside when it comes last.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	segments := strings.Split(pattern, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		if seg == "**" {
+			if last {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+		b.WriteString(globSegmentRegexp(seg))
+		if !last {
+			b.WriteString("/")
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// globSegmentRegexp translates one path segment of a pattern: "*" and "?"
+// do not match "/", "[...]" is a character class and "\" escapes the next
+// character.
+func globSegmentRegexp(seg string) string {
+	var b strings.Builder
+	for i := 0; i < len(seg); i++ {
+		switch c := seg[i]; c {
+		case '*':
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(seg) {
+				i++
+				b.WriteString(regexp.QuoteMeta(seg[i : i+1]))
+			}
+		case '[':
+			end := strings.IndexByte(seg[i+1:], ']')
+			if end <= 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := seg[i+1 : i+1+end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match reports whether rel, a path relative to the local or remote
+// directory, is ignored.
+func (m *ignoreMatcher) match(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// walkSkip tells a walk whether to skip rel, returning filepath.SkipDir
+// for ignored directories so their content is not visited.
+func (m *ignoreMatcher) walkSkip(rel string, isDir bool) (bool, error) {
+	if !m.match(rel, isDir) {
+		return false, nil
+	}
+	if isDir {
+		return true, filepath.SkipDir
+	}
+	return true, nil
+}
+
+// MarshalJSON encodes the matcher as its patterns, so actions recorded in
+// the journal keep leaving the same paths out when recovered.
+func (m *ignoreMatcher) MarshalJSON() ([]byte, error) {
+	patterns := make([]string, len(m.rules))
+	for i, rule := range m.rules {
+		patterns[i] = rule.pattern
+	}
+	return json.Marshal(patterns)
+}
+
+// UnmarshalJSON compiles the patterns written by MarshalJSON.
+func (m *ignoreMatcher) UnmarshalJSON(data []byte) error {
+	var patterns []string
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return err
+	}
+	compiled, err := newIgnoreMatcher(patterns)
+	if err != nil {
+		return err
+	}
+	*m = *compiled
+	return nil
+}
+
+// loadIgnoreFile reads the patterns of an ignore file. A missing file has
+// none.
+func loadIgnoreFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// ignorePath returns the path of the project's ignore file, kept next to
+// the configuration file.
+func (c *Config) ignorePath() string {
+	return filepath.Join(filepath.Dir(c.path()), IgnoreFileName)
+}
+
+// loadIgnores compiles the global patterns, then the ignore list of the
+// configuration and the project's ignore file, so later patterns can
+// include paths ignored by earlier ones again.
+func (c *Config) loadIgnores() error {
+	filePatterns, err := loadIgnoreFile(c.ignorePath())
+	if err != nil {
+		return err
+	}
+	var patterns []string
+	patterns = append(patterns, GetGlobalIgnore()...)
+	patterns = append(patterns, c.Ignore...)
+	patterns = append(patterns, filePatterns...)
+
+	c.ignores, err = newIgnoreMatcher(patterns)
+	return err
+}
@@ -0,0 +1,262 @@
+package lnkr
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	testCases := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{name: "basename at any depth", patterns: []string{".DS_Store"}, path: "conf/sub/.DS_Store", want: true},
+		{name: "star", patterns: []string{"*.swp"}, path: "conf/.a.txt.swp", want: true},
+		{name: "star does not cross directories", patterns: []string{"conf/*.swp"}, path: "conf/sub/a.swp", want: false},
+		{name: "question mark", patterns: []string{"a?.txt"}, path: "a1.txt", want: true},
+		{name: "character class", patterns: []string{"[ab].txt"}, path: "b.txt", want: true},
+		{name: "negated character class", patterns: []string{"[!ab].txt"}, path: "b.txt", want: false},
+		{name: "directory only matches directory", patterns: []string{"node_modules/"}, path: "app/node_modules", isDir: true, want: true},
+		{name: "directory only skips file", patterns: []string{"node_modules/"}, path: "app/node_modules", want: false},
+		{name: "leading slash anchors", patterns: []string{"/build"}, path: "app/build", isDir: true, want: false},
+		{name: "anchored match", patterns: []string{"/build"}, path: "build", isDir: true, want: true},
+		{name: "inner slash anchors", patterns: []string{"conf/cache"}, path: "app/conf/cache", want: false},
+		{name: "leading double star", patterns: []string{"**/cache"}, path: "a/b/cache", want: true},
+		{name: "inner double star", patterns: []string{"a/**/z"}, path: "a/b/c/z", want: true},
+		{name: "inner double star matches no directory", patterns: []string{"a/**/z"}, path: "a/z", want: true},
+		{name: "trailing double star", patterns: []string{"logs/**"}, path: "logs/x/y.log", want: true},
+		{name: "trailing double star leaves directory", patterns: []string{"logs/**"}, path: "logs", isDir: true, want: false},
+		{name: "negation", patterns: []string{"*.log", "!keep.log"}, path: "keep.log", want: false},
+		{name: "last match wins", patterns: []string{"!keep.log", "*.log"}, path: "keep.log", want: true},
+		{name: "escaped hash", patterns: []string{`\#notes`}, path: "#notes", want: true},
+		{name: "comment", patterns: []string{"# notes"}, path: "# notes", want: false},
+		{name: "no match", patterns: []string{"*.swp"}, path: "a.txt", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newIgnoreMatcher(tc.patterns)
+			if err != nil {
+				t.Fatalf("newIgnoreMatcher failed: %v", err)
+			}
+			if got := m.match(filepath.FromSlash(tc.path), tc.isDir); got != tc.want {
+				t.Fatalf("match(%q) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+
+	var nilMatcher *ignoreMatcher
+	if nilMatcher.match("a", false) {
+		t.Fatal("nil matcher must not ignore anything")
+	}
+	if _, err := newIgnoreMatcher([]string{"/"}); err == nil {
+		t.Fatal("expected error for an empty pattern")
+	}
+}
+
+func TestLoadConfigIgnores(t *testing.T) {
+	resetGlobalConfig(t)
+	InitGlobalConfig()
+	localDir, _ := setupProject(t, &Config{Ignore: []string{"*.log", "!keep.tmp"}})
+	if err := os.WriteFile(IgnoreFileName, []byte("# project\n*.tmp\n!keep.log\n"), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", IgnoreFileName, err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	for path, want := range map[string]bool{
+		".DS_Store": true,  // global default
+		"a.log":     true,  // configuration
+		"a.tmp":     true,  // .lnkrignore
+		"keep.tmp":  true,  // .lnkrignore comes after the configuration
+		"keep.log":  false, // included again by .lnkrignore
+		"a.txt":     false,
+	} {
+		if got := config.ignores.match(path, false); got != want {
+			t.Errorf("match(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	// Walks skip ignored paths
+	writeFiles(t, localDir, map[string]string{
+		"conf/a.txt":                "a",
+		"conf/a.log":                "log",
+		"conf/node_modules/m/x.js":  "x",
+		"conf/sub/.DS_Store":        "ds",
+		"conf/sub/keep.log":         "keep",
+		"conf/__pycache__/m.pyc":    "pyc",
+		"conf/__pycache__.txt":      "file",
+		"conf/sub/.b.txt.swp":       "swap",
+		"conf/sub/node_modules.txt": "file",
+	})
+	if err := Add([]string{"conf"}, true, LinkTypeHard, false); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	var got []string
+	for _, link := range config.Links {
+		got = append(got, link.Path)
+	}
+	want := []string{"conf/__pycache__.txt", "conf/a.txt", "conf/sub/keep.log", "conf/sub/node_modules.txt"}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected entries: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != filepath.FromSlash(want[i]) {
+			t.Fatalf("unexpected entries: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestIgnoreCopyEntry(t *testing.T) {
+	for _, linkType := range []string{LinkTypeCopy, LinkTypeReflink} {
+		t.Run(linkType, func(t *testing.T) {
+			resetGlobalConfig(t)
+			InitGlobalConfig()
+			localDir, remoteDir := setupProject(t, &Config{ReflinkFallback: ReflinkFallbackCopy, Links: []Link{{Path: "conf", Type: linkType}}})
+			writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a", "conf/.DS_Store": "remote ds"})
+			if err := CreateLinks(Selector{}, false, false, ""); err != nil {
+				t.Fatalf("CreateLinks failed: %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(localDir, "conf", ".DS_Store")); !os.IsNotExist(err) {
+				t.Fatalf("ignored file was copied to local")
+			}
+
+			// An ignored local file is not a local change and is not pushed
+			writeFiles(t, localDir, map[string]string{"conf/.DS_Store": "local ds", "conf/x.swp": "swap"})
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("loadConfig failed: %v", err)
+			}
+			if status := checkLinkStatus(config.Links[0], config); status.State != StateLinked {
+				t.Fatalf("unexpected state: %s", status.State)
+			}
+
+			writeFiles(t, localDir, map[string]string{"conf/a.txt": "local a"})
+			if err := Push(nil, false, false); err != nil {
+				t.Fatalf("Push failed: %v", err)
+			}
+			if got := readFile(t, filepath.Join(remoteDir, "conf", "a.txt")); got != "local a" {
+				t.Fatalf("unexpected remote content: %q", got)
+			}
+			if got := readFile(t, filepath.Join(remoteDir, "conf", ".DS_Store")); got != "remote ds" {
+				t.Fatalf("ignored remote file was replaced: %q", got)
+			}
+			if _, err := os.Lstat(filepath.Join(remoteDir, "conf", "x.swp")); !os.IsNotExist(err) {
+				t.Fatalf("ignored local file was pushed")
+			}
+
+			// Pulling keeps the ignored local files
+			writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "remote a"})
+			if err := Pull(nil, false, false); err != nil {
+				t.Fatalf("Pull failed: %v", err)
+			}
+			if got := readFile(t, filepath.Join(localDir, "conf", "a.txt")); got != "remote a" {
+				t.Fatalf("unexpected local content: %q", got)
+			}
+			if got := readFile(t, filepath.Join(localDir, "conf", ".DS_Store")); got != "local ds" {
+				t.Fatalf("ignored local file was replaced: %q", got)
+			}
+		})
+	}
+}
+
+func TestIgnoreAddCopyEntry(t *testing.T) {
+	resetGlobalConfig(t)
+	InitGlobalConfig()
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a", "conf/.DS_Store": "ds"})
+
+	if err := Add([]string{"conf"}, false, LinkTypeCopy, false); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// Like every other copy, the new local copy leaves the ignored file out
+	if got := readFile(t, filepath.Join(localDir, "conf", "a.txt")); got != "a" {
+		t.Fatalf("unexpected local content: %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(localDir, "conf", ".DS_Store")); !os.IsNotExist(err) {
+		t.Fatalf("ignored file was copied back to local")
+	}
+	if got := readFile(t, filepath.Join(remoteDir, "conf", ".DS_Store")); got != "ds" {
+		t.Fatalf("unexpected remote content: %q", got)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if status := checkLinkStatus(config.Links[0], config); status.State != StateLinked {
+		t.Fatalf("unexpected state: %s", status.State)
+	}
+}
+
+func TestIgnoreCopyEntryRemoval(t *testing.T) {
+	testCases := []struct {
+		name    string
+		remove  func() error
+		wantErr bool // unlink reports the entry and goes on with the others
+	}{
+		{name: "Unlink", remove: func() error { return Unlink(Selector{}, false, true) }},
+		{name: "Remove", remove: func() error { return Remove(Selector{Paths: []string{"conf"}}, false) }, wantErr: true},
+		{name: "Switch", remove: func() error { return Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false) }, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resetGlobalConfig(t)
+			InitGlobalConfig()
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "conf", Type: LinkTypeCopy}}})
+			writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a"})
+			if err := CreateLinks(Selector{}, false, false, ""); err != nil {
+				t.Fatalf("CreateLinks failed: %v", err)
+			}
+
+			// An ignored file exists only in the local copy
+			writeFiles(t, localDir, map[string]string{"conf/.DS_Store": "local ds"})
+			if err := tc.remove(); tc.wantErr && err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if got := readFile(t, filepath.Join(localDir, "conf", ".DS_Store")); got != "local ds" {
+				t.Fatalf("ignored local file was lost: %q", got)
+			}
+
+			// Once it is moved out, the copy can go
+			if err := os.Remove(filepath.Join(localDir, "conf", ".DS_Store")); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.remove(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherJSON(t *testing.T) {
+	m, err := newIgnoreMatcher([]string{"*.log", "!keep.log", "build/"})
+	if err != nil {
+		t.Fatalf("newIgnoreMatcher failed: %v", err)
+	}
+	data, err := json.Marshal(Action{Kind: ActionReplace, Ignore: m})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var a Action
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	for path, want := range map[string]bool{"a.log": true, "keep.log": false, "build": true, "a.txt": false} {
+		if got := a.Ignore.match(path, path == "build"); got != want {
+			t.Errorf("match(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -71,7 +71,7 @@ func setupConfigSymlink(config *Config) error {
 	}
 
 	// Create symbolic link using shared function
-	return createLink(remotePath, localPath, LinkTypeSymbolic)
+	return createLink(remotePath, localPath, LinkTypeSymbolic, false)
 }
 
 // createLnkTomlWithRemote creates the .lnkr.toml file with remote if it doesn't exist
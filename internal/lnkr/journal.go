@@ -0,0 +1,207 @@
+package lnkr
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JournalFileName is the name of the journal written next to the
+// configuration file while a multi-step operation is applied.
+const JournalFileName = ".lnkr.journal"
+
+// ErrJournalExists is returned when an operation is started while the
+// journal of an interrupted one is still present.
+var ErrJournalExists = fmt.Errorf("an interrupted operation was found (%s), run 'lnkr recover' first", JournalFileName)
+
+// journalHeader is the first line of the journal. It records the complete
+// plan before anything is touched.
+type journalHeader struct {
+	Operation string    `json:"operation"`
+	Started   time.Time `json:"started"`
+	Actions   []Action  `json:"actions"`
+}
+
+// journalMark is appended after an action has been applied (Done) or
+// reverted (Done false).
+type journalMark struct {
+	Index int  `json:"index"`
+	Done  bool `json:"done"`
+}
+
+// journal is an append-only log of a plan and its progress. Every line is
+// synced to disk, so after a crash the journal tells which actions took
+// effect.
+type journal struct {
+	path string
+	file *os.File
+}
+
+// beginJournal creates the journal at path and records the plan. An empty
+// path disables journaling and returns a nil journal, whose methods are
+// no-ops. An existing journal is never overwritten.
+func beginJournal(path, operation string, actions []Action) (*journal, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrJournalExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	j := &journal{path: path, file: file}
+	header := journalHeader{Operation: operation, Started: time.Now(), Actions: actions}
+	if err := j.write(header); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return j, nil
+}
+
+// openJournal opens an existing journal to append further progress.
+func openJournal(path string) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &journal{path: path, file: file}, nil
+}
+
+// mark records that the action at index has been applied or reverted.
+func (j *journal) mark(index int, done bool) error {
+	if j == nil {
+		return nil
+	}
+	return j.write(journalMark{Index: index, Done: done})
+}
+
+// finish closes and removes the journal once the operation is complete.
+func (j *journal) finish() error {
+	if j == nil {
+		return nil
+	}
+	_ = j.file.Close()
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+// close closes the journal and keeps it, so 'lnkr recover' can finish an
+// operation that could not be completed or reverted.
+func (j *journal) close() {
+	if j == nil {
+		return
+	}
+	_ = j.file.Close()
+}
+
+func (j *journal) write(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+// journalState is the replayed content of a journal.
+type journalState struct {
+	Operation string
+	Started   time.Time
+	Actions   []Action
+	// Done tells which actions were applied and not reverted.
+	Done []bool
+	// Uncertain is the index of the action that was being applied or
+	// reverted when the operation was interrupted, or -1. Its Done flag may
+	// not match what happened on disk.
+	Uncertain int
+}
+
+// readJournal replays the journal at path. A truncated last line, left by a
+// crash while writing it, is ignored.
+func readJournal(path string) (*journalState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		return nil, fmt.Errorf("journal is empty: %s", path)
+	}
+	var header journalHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, fmt.Errorf("failed to decode journal %s: %w", path, err)
+	}
+
+	// Configurations in the journal are saved next to the journal itself.
+	dir := filepath.Dir(path)
+	for i := range header.Actions {
+		if c := header.Actions[i].Config; c != nil {
+			c.dir = dir
+		}
+		if c := header.Actions[i].Prev; c != nil {
+			c.dir = dir
+		}
+	}
+
+	state := &journalState{
+		Operation: header.Operation,
+		Started:   header.Started,
+		Actions:   header.Actions,
+		Done:      make([]bool, len(header.Actions)),
+	}
+	reverting := false
+	for scanner.Scan() {
+		var m journalMark
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			break
+		}
+		if m.Index < 0 || m.Index >= len(state.Actions) {
+			continue
+		}
+		state.Done[m.Index] = m.Done
+		reverting = !m.Done
+		state.Uncertain = m.Index + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	// Actions are applied in order and reverted in reverse order, so the
+	// interrupted one follows the last applied action, or is the last
+	// action still applied while reverting.
+	if reverting {
+		state.Uncertain = -1
+		for i := len(state.Done) - 1; i >= 0; i-- {
+			if state.Done[i] {
+				state.Uncertain = i
+				break
+			}
+		}
+	}
+	if state.Uncertain >= len(state.Actions) {
+		state.Uncertain = -1
+	}
+	return state, nil
+}
@@ -0,0 +1,86 @@
+package lnkr
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	actions := []Action{
+		{Kind: ActionMkdir, Entry: "a", Target: "/r"},
+		{Kind: ActionMove, Entry: "a", Source: "/l/a", Target: "/r/a"},
+		{Kind: ActionConfig, Target: ConfigFileName, Config: &Config{Links: []Link{{Path: "a", Type: LinkTypeSymbolic}}}},
+	}
+	j, err := beginJournal(JournalFileName, "add", actions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, mark := range []struct {
+		index int
+		done  bool
+	}{{0, true}, {1, true}, {1, false}} {
+		if err := j.mark(mark.index, mark.done); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// A torn last line left by a crash must be ignored.
+	if _, err := j.file.WriteString(`{"index":2,"do`); err != nil {
+		t.Fatalf("failed to write partial line: %v", err)
+	}
+
+	state, err := readJournal(JournalFileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Operation != "add" || len(state.Actions) != len(actions) {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if want := []bool{true, false, false}; !slices.Equal(state.Done, want) {
+		t.Fatalf("unexpected done flags: got %v, want %v", state.Done, want)
+	}
+	if state.Actions[2].Config.dir == "" {
+		t.Fatalf("journaled configuration is not anchored at the journal directory")
+	}
+
+	// An existing journal is never overwritten.
+	if _, err := beginJournal(JournalFileName, "remove", nil); !errors.Is(err, ErrJournalExists) {
+		t.Fatalf("expected ErrJournalExists, got %v", err)
+	}
+
+	if err := j.finish(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(JournalFileName); !os.IsNotExist(err) {
+		t.Fatalf("journal still exists after finish")
+	}
+}
+
+func TestJournalDisabled(t *testing.T) {
+	j, err := beginJournal("", "add", nil)
+	if err != nil || j != nil {
+		t.Fatalf("expected nil journal, got %v, %v", j, err)
+	}
+	if err := j.mark(0, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := j.finish(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestApplyRefusesWithInterruptedJournal(t *testing.T) {
+	localDir, _ := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+	if err := os.WriteFile(JournalFileName, []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("failed to write journal: %v", err)
+	}
+
+	if err := Add([]string{"a.txt"}, false, LinkTypeSymbolic, false); !errors.Is(err, ErrJournalExists) {
+		t.Fatalf("expected ErrJournalExists, got %v", err)
+	}
+}
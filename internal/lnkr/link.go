@@ -6,9 +6,21 @@ import (
 	"path/filepath"
 )
 
-// CreateLinks creates links based on configuration.
+// CreateLinks creates links for the entries selected by sel.
 // Links are always created from remote to local (remote is the source, local is the link).
-func CreateLinks() error {
+// With onlyAvailable, entries whose remote path does not exist yet (e.g. not
+// synced) are skipped instead of counted as errors.
+// onConflict is the strategy for local paths that exist but are not linked
+// (see ConflictError and friends); empty means ConflictError.
+// With dryRun, the planned actions are printed instead of applied.
+func CreateLinks(sel Selector, onlyAvailable, dryRun bool, onConflict string) error {
+	if onConflict == "" {
+		onConflict = ConflictError
+	}
+	if !ValidConflictStrategy(onConflict) {
+		return fmt.Errorf("invalid conflict strategy: %s. Must be '%s', '%s', '%s', '%s', '%s' or '%s'", onConflict, ConflictError, ConflictSkip, ConflictBackup, ConflictAdoptLocal, ConflictOverwrite, ConflictAsk)
+	}
+
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -18,43 +30,85 @@ func CreateLinks() error {
 		fmt.Printf("No links found in %s\n", ConfigFileName)
 		return nil
 	}
+	selected, err := selectEntries(config, sel)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Println("No matching links found.")
+		return nil
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
 
-	var errorCount int
-	for _, link := range config.Links {
-		if err := createLinkEntry(link, config); err != nil {
+	var errorCount, skipCount int
+	plan := &Plan{}
+	for _, link := range selected {
+		if onlyAvailable {
+			if _, err := os.Stat(filepath.Join(remoteDir, link.Path)); os.IsNotExist(err) {
+				fmt.Printf("Skipping (not available in remote yet): %s\n", link.Path)
+				skipCount++
+				continue
+			}
+		}
+		actions, err := planLinkEntry(link, config, onConflict, dryRun)
+		if err != nil {
 			fmt.Printf("Error creating link for %s: %v\n", link.Path, err)
 			errorCount++
 			continue
 		}
+		plan.add(actions...)
 	}
 
 	// Apply all link paths to GitExclude
-	if err := applyAllLinksToGitExclude(config); err != nil {
-		fmt.Printf("Warning: failed to apply link paths to GitExclude: %v\n", err)
+	plan.add(excludeAction(config, nil))
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d link(s) would be created, %d skipped, %d error(s).\n", plan.count(ActionLink), skipCount, errorCount)
+		return nil
 	}
 
-	totalCount := len(config.Links)
-	successCount := totalCount - errorCount
+	failed, err := plan.ApplyEach(func(entry string, err error) {
+		fmt.Printf("Error creating link for %s: %v\n", entry, err)
+	})
+	if err != nil {
+		return err
+	}
+	errorCount += failed
+
+	totalCount := len(selected)
+	successCount := totalCount - errorCount - skipCount
+	var skipped string
+	if skipCount > 0 {
+		skipped = fmt.Sprintf(", %d skipped", skipCount)
+	}
 	if errorCount == 0 {
-		fmt.Printf("Link creation completed. (%d/%d succeeded)\n", successCount, totalCount)
+		fmt.Printf("Link creation completed. (%d/%d succeeded%s)\n", successCount, totalCount, skipped)
 	} else if successCount == 0 {
-		fmt.Printf("Link creation failed. (%d/%d failed)\n", errorCount, totalCount)
+		fmt.Printf("Link creation failed. (%d/%d failed%s)\n", errorCount, totalCount, skipped)
 		return fmt.Errorf("all %d links failed to create", errorCount)
 	} else {
-		fmt.Printf("Link creation completed with errors. (%d/%d succeeded, %d failed)\n", successCount, totalCount, errorCount)
+		fmt.Printf("Link creation completed with errors. (%d/%d succeeded, %d failed%s)\n", successCount, totalCount, errorCount, skipped)
 	}
 	return nil
 }
 
-func createLinkEntry(link Link, config *Config) error {
+// planLinkEntry plans the actions needed to link a single entry. Entries
+// that are already linked yield no actions; a conflicting local path is
+// dealt with according to onConflict, by default an error so local files
+// are never masked.
+func planLinkEntry(link Link, config *Config, onConflict string, dryRun bool) ([]Action, error) {
 	// Source is always remote, target is always local
 	sourceDir, err := config.GetRemoteExpanded()
 	if err != nil {
-		return fmt.Errorf("failed to expand remote path: %w", err)
+		return nil, fmt.Errorf("failed to expand remote path: %w", err)
 	}
 	targetDir, err := config.GetLocalExpanded()
 	if err != nil {
-		return fmt.Errorf("failed to expand local path: %w", err)
+		return nil, fmt.Errorf("failed to expand local path: %w", err)
 	}
 
 	// Resolve absolute paths for source and target
@@ -64,75 +118,157 @@ func createLinkEntry(link Link, config *Config) error {
 	// Check if source exists
 	sourceInfo, err := os.Stat(sourceAbs)
 	if os.IsNotExist(err) {
-		return fmt.Errorf("source path does not exist: %s", sourceAbs)
+		return nil, fmt.Errorf("source path does not exist: %s", sourceAbs)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to stat source path: %w", err)
+		return nil, fmt.Errorf("failed to stat source path: %w", err)
 	}
 
+	var actions []Action
+
 	// Create parent directory if needed
 	targetParentDir := filepath.Dir(targetAbs)
-	if err := os.MkdirAll(targetParentDir, 0755); err != nil {
-		return fmt.Errorf("failed to create target directory: %w", err)
+	if _, err := os.Stat(targetParentDir); os.IsNotExist(err) {
+		actions = append(actions, Action{Kind: ActionMkdir, Entry: link.Path, Target: targetParentDir})
+	}
+
+	// conflict resolves an existing local path that is not linked to the
+	// remote path
+	conflict := func(localPath, remotePath string, err error) (conflictResolution, error) {
+		return resolveConflict(link.Path, localPath, remotePath, onConflict, err, dryRun)
 	}
 
+	var linkActions []Action
 	switch link.Type {
 	case LinkTypeHard:
+		hint := fmt.Sprintf("run 'lnkr switch %s %s' to use a symbolic link instead", link.Path, LinkTypeSymbolic)
+		if err := checkSameDevice(targetAbs, sourceAbs, link.Type, hint); err != nil {
+			return nil, err
+		}
 		if sourceInfo.IsDir() {
-			// For directories, recursively create hard links for all files
-			if err := createHardLinksRecursively(sourceAbs, targetAbs); err != nil {
-				return fmt.Errorf("failed to create hard links for directory: %w", err)
+			// For directories, create hard links for all files
+			linkActions, err = planHardLinksRecursively(link.Path, sourceAbs, targetAbs, config.ignores, conflict)
+			if err != nil {
+				return nil, fmt.Errorf("failed to plan hard links for directory: %w", err)
 			}
-			return nil
+		} else {
+			linkActions, err = planHardLink(link.Path, sourceAbs, sourceInfo, targetAbs, conflict)
 		}
-		return createHardLinkIdempotent(sourceAbs, sourceInfo, targetAbs)
 	case LinkTypeSymbolic:
-		return createSymlinkIdempotent(sourceAbs, targetAbs)
+		linkActions, err = planSymlink(link.Path, sourceAbs, targetAbs, config.relativeSymlink(link), conflict)
+	case LinkTypeCopy, LinkTypeReflink:
+		if needsSameDevice(link.Type, config) {
+			if err := checkSameDevice(targetAbs, sourceAbs, link.Type, reflinkFallbackHint); err != nil {
+				return nil, err
+			}
+		}
+		linkActions, err = planCopy(link.Path, link.Type, sourceAbs, targetAbs, config, conflict)
 	default:
-		return fmt.Errorf("unknown link type: %s", link.Type)
+		return nil, fmt.Errorf("unknown link type: %s", link.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if len(linkActions) == 0 {
+		return nil, nil
+	}
+	return append(actions, linkActions...), nil
 }
 
-// createSymlinkIdempotent creates a symbolic link, treating an existing link
-// that already points to the source as success. An existing target that is
-// anything else is an error so conflicting local files are never masked.
-func createSymlinkIdempotent(sourceAbs, targetAbs string) error {
+// conflictFunc resolves an existing local path that is not linked to the
+// remote path; err describes the conflict.
+type conflictFunc func(localPath, remotePath string, err error) (conflictResolution, error)
+
+// planSymlink plans a symbolic link, treating an existing link that already
+// points to the source, with an absolute or relative target, as done. Any
+// other existing target is resolved by conflict.
+func planSymlink(entry, sourceAbs, targetAbs string, relative bool, conflict conflictFunc) ([]Action, error) {
+	link := Action{Kind: ActionLink, Entry: entry, Source: sourceAbs, Target: targetAbs, LinkType: LinkTypeSymbolic, Relative: relative}
 	if fi, err := os.Lstat(targetAbs); err == nil {
 		if fi.Mode()&os.ModeSymlink != 0 {
-			if target, err := os.Readlink(targetAbs); err == nil && target == sourceAbs {
+			if target, err := os.Readlink(targetAbs); err == nil && symlinkPointsTo(targetAbs, target, sourceAbs) {
 				fmt.Printf("Already linked: %s\n", targetAbs)
-				return nil
+				return nil, nil
 			}
 		}
-		return fmt.Errorf("target already exists and is not a link to %s: %s", sourceAbs, targetAbs)
-	}
-	if err := os.Symlink(sourceAbs, targetAbs); err != nil {
-		return fmt.Errorf("failed to create symbolic link: %w", err)
+		r, err := conflict(targetAbs, sourceAbs, fmt.Errorf("target already exists and is not a link to %s: %s", sourceAbs, targetAbs))
+		if err != nil {
+			return nil, err
+		}
+		return r.wrap(link), nil
 	}
-	fmt.Printf("Created symbolic link: %s -> %s\n", targetAbs, sourceAbs)
-	return nil
+	return []Action{link}, nil
 }
 
-// createHardLinkIdempotent creates a hard link, treating an existing target
-// that already shares the source's inode as success.
-func createHardLinkIdempotent(sourceAbs string, sourceInfo os.FileInfo, targetAbs string) error {
+// planHardLink plans a hard link, treating an existing target that already
+// shares the source's inode as done. Any other existing target is resolved
+// by conflict.
+func planHardLink(entry, sourceAbs string, sourceInfo os.FileInfo, targetAbs string, conflict conflictFunc) ([]Action, error) {
+	link := Action{Kind: ActionLink, Entry: entry, Source: sourceAbs, Target: targetAbs, LinkType: LinkTypeHard}
 	if fi, err := os.Lstat(targetAbs); err == nil {
 		if os.SameFile(fi, sourceInfo) {
 			fmt.Printf("Already linked: %s\n", targetAbs)
-			return nil
+			return nil, nil
+		}
+		r, err := conflict(targetAbs, sourceAbs, fmt.Errorf("target already exists and is not a hard link to %s: %s", sourceAbs, targetAbs))
+		if err != nil {
+			return nil, err
 		}
-		return fmt.Errorf("target already exists and is not a hard link to %s: %s", sourceAbs, targetAbs)
+		return r.wrap(link), nil
 	}
-	if err := os.Link(sourceAbs, targetAbs); err != nil {
-		return fmt.Errorf("failed to create hard link: %w", err)
+	return []Action{link}, nil
+}
+
+// planCopy plans copying (or cloning, for reflinks) the source to the
+// target and recording the copied content as synced. An existing copy with
+// the same content is treated as done; any other existing target is
+// resolved by conflict.
+func planCopy(entry, linkType, sourceAbs, targetAbs string, config *Config, conflict conflictFunc) ([]Action, error) {
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return nil, err
 	}
-	fmt.Printf("Created hard link: %s -> %s\n", targetAbs, sourceAbs)
-	return nil
+	hash, err := hashPath(sourceAbs, entry, config.ignores)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", sourceAbs, err)
+	}
+
+	if fi, err := os.Lstat(targetAbs); err == nil {
+		if fi.Mode()&os.ModeSymlink == 0 {
+			if localHash, err := hashPath(targetAbs, entry, config.ignores); err == nil && localHash == hash {
+				fmt.Printf("Already linked: %s\n", targetAbs)
+				if state.Copies[entry] == hash {
+					return nil, nil
+				}
+				return []Action{recordAction(state, entry, hash)}, nil
+			}
+		}
+		r, err := conflict(targetAbs, sourceAbs, fmt.Errorf("target already exists and differs from %s: %s", sourceAbs, targetAbs))
+		if err != nil {
+			return nil, err
+		}
+		if r.adopted {
+			// The local content becomes remote, so it is what was synced
+			if hash, err = hashPath(targetAbs, entry, config.ignores); err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", targetAbs, err)
+			}
+		}
+		return r.wrap(
+			Action{Kind: ActionLink, Entry: entry, Source: sourceAbs, Target: targetAbs, LinkType: linkType, CopyFallback: config.copyFallback(), Ignore: config.ignores},
+			recordAction(state, entry, hash),
+		), nil
+	}
+	return []Action{
+		{Kind: ActionLink, Entry: entry, Source: sourceAbs, Target: targetAbs, LinkType: linkType, CopyFallback: config.copyFallback(), Ignore: config.ignores},
+		recordAction(state, entry, hash),
+	}, nil
 }
 
-// createHardLinksRecursively walks the source directory and creates hard links for all files
-func createHardLinksRecursively(sourceDir, targetDir string) error {
-	var fileCount int
+// planHardLinksRecursively walks the source directory and plans hard links
+// for all files that are not linked yet and not ignored, creating missing
+// directories. Conflicting files are resolved by conflict.
+func planHardLinksRecursively(entry, sourceDir, targetDir string, ignore *ignoreMatcher, conflict conflictFunc) ([]Action, error) {
+	var actions []Action
 	err := filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -144,40 +280,42 @@ func createHardLinksRecursively(sourceDir, targetDir string) error {
 			return fmt.Errorf("failed to get relative path: %w", err)
 		}
 
+		if path != sourceDir {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, relPath), info.IsDir()); skip {
+				return err
+			}
+		}
 		targetPath := filepath.Join(targetDir, relPath)
 
 		if info.IsDir() {
 			// Create directory structure
-			if err := os.MkdirAll(targetPath, info.Mode()); err != nil {
-				return fmt.Errorf("failed to create directory %s: %w", targetPath, err)
+			if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+				actions = append(actions, Action{Kind: ActionMkdir, Entry: entry, Target: targetPath, Mode: info.Mode().Perm()})
 			}
 			return nil
 		}
 
-		// Skip files that are already hard linked; a conflicting file is an error
+		// Skip files that are already hard linked and resolve conflicting ones
+		link := Action{Kind: ActionLink, Entry: entry, Source: path, Target: targetPath, LinkType: LinkTypeHard}
 		if fi, err := os.Lstat(targetPath); err == nil {
 			if os.SameFile(fi, info) {
 				return nil
 			}
-			return fmt.Errorf("target already exists and is not a hard link to %s: %s", path, targetPath)
-		}
-
-		// Create hard link for file
-		if err := os.Link(path, targetPath); err != nil {
-			return fmt.Errorf("failed to create hard link %s -> %s: %w", targetPath, path, err)
+			r, err := conflict(targetPath, path, fmt.Errorf("target already exists and is not a hard link to %s: %s", path, targetPath))
+			if err != nil {
+				return err
+			}
+			actions = append(actions, r.wrap(link)...)
+			return nil
 		}
-		fmt.Printf("Created hard link: %s -> %s\n", targetPath, path)
-		fileCount++
 
+		actions = append(actions, link)
 		return nil
 	})
-
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	fmt.Printf("Created %d hard links for directory: %s\n", fileCount, sourceDir)
-	return nil
+	return actions, nil
 }
 
 // applyAllLinksToGitExclude removes existing LNKR section and applies all configured link paths to GitExclude
@@ -186,8 +324,8 @@ func applyAllLinksToGitExclude(config *Config) error {
 	// Continue even if removal fails (section might not exist).
 	_, _ = removeGitExcludeSection(config.GetGitExcludePath())
 
-	// Always include .lnkr.toml in the exclude list
-	linkPaths := []string{ConfigFileName}
+	// Always include .lnkr.toml, its journal and sync state in the exclude list
+	linkPaths := []string{ConfigFileName, JournalFileName, StateFileName}
 	for _, link := range config.Links {
 		linkPaths = append(linkPaths, link.Path)
 	}
@@ -75,7 +75,7 @@ func TestCreateLinks(t *testing.T) {
 			localDir, remoteDir := setupProject(t, &Config{Links: tc.links})
 			writeFiles(t, remoteDir, tc.remoteFiles)
 
-			err := CreateLinks()
+			err := CreateLinks(Selector{}, false, false, ConflictError)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error but got none")
@@ -107,11 +107,51 @@ func TestCreateLinks(t *testing.T) {
 func TestCreateLinksNoLinks(t *testing.T) {
 	setupProject(t, &Config{Links: []Link{}})
 
-	if err := CreateLinks(); err != nil {
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+func TestCreateLinksSelected(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sel           Selector
+		onlyAvailable bool
+		wantLinked    []string
+	}{
+		{name: "Paths", sel: Selector{Paths: []string{"config/", ".env"}}, wantLinked: []string{".env", "config/a.yaml"}},
+		{name: "Type", sel: Selector{Types: []string{LinkTypeHard}}, wantLinked: []string{"hard.txt"}},
+		{name: "OnlyAvailable", onlyAvailable: true, wantLinked: []string{".env", "config/a.yaml", "hard.txt"}},
+		{name: "UnavailableFailsAlone", sel: Selector{Paths: []string{"config"}}, wantLinked: []string{"config/a.yaml"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{
+				{Path: ".env", Type: LinkTypeSymbolic},
+				{Path: "config/a.yaml", Type: LinkTypeSymbolic},
+				{Path: "config/b.yaml", Type: LinkTypeSymbolic}, // not synced yet
+				{Path: "hard.txt", Type: LinkTypeHard},
+			}})
+			writeFiles(t, remoteDir, map[string]string{".env": "e", "config/a.yaml": "a", "hard.txt": "h"})
+			if err := os.MkdirAll(filepath.Join(localDir, "config"), 0755); err != nil {
+				t.Fatalf("failed to create local dir: %v", err)
+			}
+
+			if err := CreateLinks(tc.sel, tc.onlyAvailable, false, ConflictError); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, path := range []string{".env", "config/a.yaml", "config/b.yaml", "hard.txt"} {
+				_, err := os.Lstat(filepath.Join(localDir, path))
+				if linked := err == nil; linked != slices.Contains(tc.wantLinked, path) {
+					t.Fatalf("unexpected link state of %s: linked %v, want %v", path, linked, !linked)
+				}
+			}
+		})
+	}
+}
+
 func TestCreateLinksFailsOnConflictingTarget(t *testing.T) {
 	localDir, remoteDir := setupProject(t, &Config{
 		Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
@@ -120,7 +160,7 @@ func TestCreateLinksFailsOnConflictingTarget(t *testing.T) {
 	writeFiles(t, localDir, map[string]string{"a.txt": "local"})
 
 	// A local file that is not a link to remote must be reported as an error.
-	if err := CreateLinks(); err == nil {
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err == nil {
 		t.Fatalf("expected error for conflicting target, but got none")
 	}
 
@@ -177,10 +217,10 @@ func TestCreateLinksIdempotent(t *testing.T) {
 			writeFiles(t, remoteDir, tc.remoteFiles)
 
 			// Running twice must succeed with all links intact.
-			if err := CreateLinks(); err != nil {
+			if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 				t.Fatalf("unexpected error on first run: %v", err)
 			}
-			if err := CreateLinks(); err != nil {
+			if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 				t.Fatalf("unexpected error on second run: %v", err)
 			}
 
@@ -190,3 +230,22 @@ func TestCreateLinksIdempotent(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateLinksDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+	})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+
+	if err := CreateLinks(Selector{}, false, true, ConflictError); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing must be created during a dry run.
+	if _, err := os.Lstat(filepath.Join(localDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("link was created during dry run")
+	}
+	if _, err := os.Stat(GitExcludePath); !os.IsNotExist(err) {
+		t.Fatalf("git exclude was written during dry run")
+	}
+}
@@ -0,0 +1,434 @@
+package lnkr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// copySuffix is appended to the destination while a cross-filesystem copy
+// is in progress, so a partial copy never appears under the final name.
+const copySuffix = ".lnkr-tmp"
+
+// Progress is reported for copies larger than these thresholds.
+const (
+	progressMinBytes = 64 << 20
+	progressMinFiles = 200
+)
+
+// rename is os.Rename, replaceable in tests to simulate EXDEV.
+var rename = os.Rename
+
+// movePath moves src to dst. When they are on different filesystems and
+// rename fails with EXDEV, the tree is copied (keeping modes, mtimes,
+// symlinks and, where possible, ownership and extended attributes), the
+// copy is verified by size and hash, and only then is src removed.
+func movePath(src, dst string) error {
+	err := rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	return moveAcrossDevices(src, dst)
+}
+
+func moveAcrossDevices(src, dst string) error {
+	usage, err := diskUsage(src)
+	if err != nil {
+		return fmt.Errorf("failed to measure %s: %w", src, err)
+	}
+	if err := checkFreeSpace(filepath.Dir(dst), usage.bytes); err != nil {
+		return err
+	}
+
+	fmt.Printf("Copying across filesystems: %s -> %s (%d file(s), %s)\n", src, dst, usage.files, formatBytes(usage.bytes))
+	tmp, err := stageCopy(src, dst, usage, cloneNever, "", nil)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.RemoveAll(tmp)
+		return fmt.Errorf("failed to move copy into place at %s: %w", dst, err)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("copied %s to %s but failed to remove the source: %w", src, dst, err)
+	}
+	return nil
+}
+
+// stageCopy copies src next to dst under a temporary name and verifies the
+// copy by hash. It returns the temporary path, which the caller renames into
+// place; on failure nothing is left behind. Mode selects whether file data
+// is cloned.
+func stageCopy(src, dst string, usage treeUsage, mode cloneMode, entry string, ignore *ignoreMatcher) (string, error) {
+	tmp := dst + copySuffix
+	_ = os.RemoveAll(tmp) // left over from an interrupted copy
+
+	hashes, err := copyTree(src, tmp, newCopyProgress(usage), mode, entry, ignore)
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+	}
+	if err := verifyTree(tmp, hashes); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", fmt.Errorf("copy of %s failed verification: %w", src, err)
+	}
+	return tmp, nil
+}
+
+// treeUsage is the number of regular files and their total size in a tree.
+type treeUsage struct {
+	files int
+	bytes int64
+}
+
+func diskUsage(root string) (treeUsage, error) {
+	var usage treeUsage
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.files++
+		usage.bytes += info.Size()
+		return nil
+	})
+	return usage, err
+}
+
+// checkFreeSpace fails when the filesystem holding dir (or its nearest
+// existing ancestor) has less than need bytes available.
+func checkFreeSpace(dir string, need int64) error {
+	existing := nearestExisting(dir)
+	var st unix.Statfs_t
+	if err := unix.Statfs(existing, &st); err != nil {
+		// Free space is unknown; let the copy itself report a full disk.
+		return nil
+	}
+	avail := uint64(st.Bavail) * uint64(st.Bsize)
+	if need > 0 && uint64(need) > avail {
+		return fmt.Errorf("not enough free space on the filesystem of %s: need %s, available %s", existing, formatBytes(need), formatBytes(int64(avail)))
+	}
+	return nil
+}
+
+// nearestExisting returns path or its closest ancestor that exists.
+func nearestExisting(path string) string {
+	for {
+		if _, err := os.Lstat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// crossDevice reports whether moving src to dst crosses filesystems.
+// Unknown devices are treated as the same filesystem.
+func crossDevice(src, dst string) bool {
+	srcDev, errSrc := deviceOf(src)
+	dstDev, errDst := deviceOf(filepath.Dir(dst))
+	return errSrc == nil && errDst == nil && srcDev != dstDev
+}
+
+// preflightMoves checks up front that every move crossing filesystems fits
+// into the free space of its destination.
+func preflightMoves(actions []Action) error {
+	need := make(map[uint64]int64)
+	dirs := make(map[uint64]string)
+	for _, a := range actions {
+		if a.Kind != ActionMove || !crossDevice(a.Source, a.Target) {
+			continue
+		}
+		usage, err := diskUsage(a.Source)
+		if err != nil {
+			continue // reported when the move runs
+		}
+		dev, err := deviceOf(filepath.Dir(a.Target))
+		if err != nil {
+			continue
+		}
+		need[dev] += usage.bytes
+		dirs[dev] = filepath.Dir(a.Target)
+	}
+	for dev, bytes := range need {
+		if err := checkFreeSpace(dirs[dev], bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree copies src to dst and returns the SHA-256 of every regular file
+// keyed by its path relative to src. Clone selects whether file data is
+// cloned. Src holds entry; the paths inside it that ignore matches are left
+// out.
+func copyTree(src, dst string, progress *copyProgress, clone cloneMode, entry string, ignore *ignoreMatcher) (map[string][]byte, error) {
+	hashes := make(map[string][]byte)
+	type dirTimes struct {
+		src  string
+		path string
+		info os.FileInfo
+	}
+	var dirs []dirTimes
+
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if p != src {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, rel), d.IsDir()); skip {
+				return err
+			}
+		}
+		target := filepath.Join(dst, rel)
+		info, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+
+		switch mode := info.Mode(); {
+		case mode.IsDir():
+			// Owner write permission is needed while filling the directory;
+			// the original mode is restored afterwards.
+			if err := os.Mkdir(target, mode.Perm()|0700); err != nil {
+				return err
+			}
+			dirs = append(dirs, dirTimes{p, target, info})
+		case mode&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+			copyOwner(target, info)
+		case mode.IsRegular():
+			sum, err := copyFile(p, target, info, progress, clone)
+			if err != nil {
+				return err
+			}
+			hashes[rel] = sum
+		default:
+			return fmt.Errorf("unsupported file type: %s", p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply directory modes and times bottom-up, after their contents.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := dirs[i]
+		copyXattrs(d.src, d.path)
+		copyOwner(d.path, d.info)
+		if err := os.Chmod(d.path, d.info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+		if err := os.Chtimes(d.path, time.Now(), d.info.ModTime()); err != nil {
+			return nil, err
+		}
+	}
+	progress.done()
+	return hashes, nil
+}
+
+// copyFile copies (or, depending on mode, clones) a regular file with its
+// mode, mtime, ownership and extended attributes, returning the SHA-256 of
+// the data read from src.
+func copyFile(src, dst string, info os.FileInfo, progress *copyProgress, mode cloneMode) ([]byte, error) {
+	var sum []byte
+	err := ErrReflinkUnsupported
+	if mode != cloneNever {
+		if err = cloneFile(src, dst, info.Mode().Perm()); err == nil {
+			sum, err = hashFile(src)
+			if err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, ErrReflinkUnsupported) || mode == cloneOnly {
+			return nil, err
+		}
+	}
+	if err != nil {
+		if sum, err = writeCopy(src, dst, info.Mode().Perm()); err != nil {
+			return nil, err
+		}
+	}
+
+	copyXattrs(src, dst)
+	copyOwner(dst, info)
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	if err := os.Chtimes(dst, time.Now(), info.ModTime()); err != nil {
+		return nil, err
+	}
+	progress.add(info.Size())
+	return sum, nil
+}
+
+// writeCopy writes the data of src to the new file dst and syncs it,
+// returning the SHA-256 of the data.
+func writeCopy(src, dst string, perm os.FileMode) ([]byte, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), in); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// copyOwner copies ownership when permitted (e.g. running as root).
+func copyOwner(dst string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = os.Lchown(dst, int(st.Uid), int(st.Gid))
+	}
+}
+
+// copyXattrs copies extended attributes where the filesystems support them.
+// Failures are ignored: attributes are kept where possible, not required.
+func copyXattrs(src, dst string) {
+	size, err := unix.Listxattr(src, nil)
+	if err != nil || size <= 0 {
+		return
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(src, buf)
+	if err != nil {
+		return
+	}
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		valueSize, err := unix.Getxattr(src, string(name), nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = unix.Getxattr(src, string(name), value)
+		if err != nil {
+			continue
+		}
+		_ = unix.Setxattr(dst, string(name), value[:valueSize], 0)
+	}
+}
+
+// verifyTree checks that every file copied into root has the expected hash.
+func verifyTree(root string, hashes map[string][]byte) error {
+	for rel, want := range hashes {
+		got, err := hashFile(filepath.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("content mismatch: %s", rel)
+		}
+	}
+	return nil
+}
+
+// hashFile returns the SHA-256 of a file's content.
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// copyProgress prints the progress of large copies in 10% steps.
+type copyProgress struct {
+	total     treeUsage
+	copied    treeUsage
+	nextShown int
+	enabled   bool
+}
+
+func newCopyProgress(total treeUsage) *copyProgress {
+	return &copyProgress{
+		total:     total,
+		nextShown: 10,
+		enabled:   total.bytes >= progressMinBytes || total.files >= progressMinFiles,
+	}
+}
+
+func (p *copyProgress) add(size int64) {
+	p.copied.files++
+	p.copied.bytes += size
+	if !p.enabled || p.total.bytes == 0 {
+		return
+	}
+	percent := int(p.copied.bytes * 100 / p.total.bytes)
+	if percent >= p.nextShown && percent < 100 {
+		fmt.Printf("  %d%% (%d/%d file(s), %s/%s)\n", percent, p.copied.files, p.total.files, formatBytes(p.copied.bytes), formatBytes(p.total.bytes))
+		p.nextShown = percent/10*10 + 10
+	}
+}
+
+func (p *copyProgress) done() {
+	if p.enabled {
+		fmt.Printf("  100%% (%d/%d file(s), %s)\n", p.copied.files, p.total.files, formatBytes(p.copied.bytes))
+	}
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,170 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// simulateEXDEV makes rename fail as if src and dst were on different
+// filesystems for the duration of the test.
+func simulateEXDEV(t *testing.T) {
+	t.Helper()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = os.Rename })
+}
+
+func TestMovePathAcrossDevices(t *testing.T) {
+	simulateEXDEV(t)
+
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	writeFiles(t, src, map[string]string{
+		"a.txt":      "alpha",
+		"sub/b.sh":   "#!/bin/sh\n",
+		"sub/deep/c": "gamma",
+	})
+	if err := os.MkdirAll(filepath.Join(src, "sub", "empty"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(src, "sub", "b.sh"), 0750); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, p := range []string{"a.txt", "sub"} {
+		if err := os.Chtimes(filepath.Join(src, p), mtime, mtime); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+	}
+
+	if err := movePath(src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Lstat(src); !os.IsNotExist(err) {
+		t.Fatalf("expected source to be removed, got %v", err)
+	}
+	if _, err := os.Lstat(dst + copySuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected temporary copy to be gone, got %v", err)
+	}
+
+	for rel, want := range map[string]string{"a.txt": "alpha", "sub/b.sh": "#!/bin/sh\n", "sub/deep/c": "gamma"} {
+		got, err := os.ReadFile(filepath.Join(dst, rel))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", rel, err)
+		}
+		if string(got) != want {
+			t.Fatalf("unexpected content of %s: got %q, want %q", rel, got, want)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(dst, "sub", "empty")); err != nil || !fi.IsDir() {
+		t.Fatalf("expected empty directory to be copied: %v", err)
+	}
+
+	fi, err := os.Stat(filepath.Join(dst, "sub", "b.sh"))
+	if err != nil {
+		t.Fatalf("failed to stat copied file: %v", err)
+	}
+	if fi.Mode().Perm() != 0750 {
+		t.Fatalf("mode not preserved: got %v, want %v", fi.Mode().Perm(), os.FileMode(0750))
+	}
+	for _, p := range []string{"a.txt", "sub"} {
+		fi, err := os.Stat(filepath.Join(dst, p))
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", p, err)
+		}
+		if !fi.ModTime().Equal(mtime) {
+			t.Fatalf("mtime of %s not preserved: got %v, want %v", p, fi.ModTime(), mtime)
+		}
+	}
+
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	if err != nil {
+		t.Fatalf("expected symlink to be preserved: %v", err)
+	}
+	if link != "a.txt" {
+		t.Fatalf("unexpected symlink target: got %q, want %q", link, "a.txt")
+	}
+}
+
+func TestMovePathAcrossDevicesKeepsSourceOnFailure(t *testing.T) {
+	simulateEXDEV(t)
+
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	writeFiles(t, src, map[string]string{"a.txt": "alpha"})
+
+	// The destination's parent is a file, so the copy cannot be created.
+	blocker := filepath.Join(tempDir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("failed to write blocker: %v", err)
+	}
+
+	if err := movePath(src, filepath.Join(blocker, "dst")); err == nil {
+		t.Fatalf("expected error, but got none")
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatalf("expected source to be kept: %v", err)
+	}
+}
+
+func TestAddAcrossDevices(t *testing.T) {
+	simulateEXDEV(t)
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"dir/a.txt": "alpha"})
+
+	if err := Add([]string{filepath.Join(localDir, "dir")}, false, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertLink(t, filepath.Join(localDir, "dir"), filepath.Join(remoteDir, "dir"), LinkTypeSymbolic)
+	got, err := os.ReadFile(filepath.Join(remoteDir, "dir", "a.txt"))
+	if err != nil {
+		t.Fatalf("failed to read moved file: %v", err)
+	}
+	if string(got) != "alpha" {
+		t.Fatalf("unexpected content: got %q, want %q", got, "alpha")
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkFreeSpace(filepath.Join(dir, "missing", "child"), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := checkFreeSpace(dir, 1<<62)
+	if err == nil {
+		t.Fatalf("expected error for an impossible size, but got none")
+	}
+	if !strings.Contains(err.Error(), "not enough free space") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	testCases := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tc := range testCases {
+		if got := formatBytes(tc.n); got != tc.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tc.n, got, tc.want)
+		}
+	}
+}
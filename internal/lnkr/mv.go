@@ -0,0 +1,222 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Move renames the entry at oldPath to newPath: the remote file or directory
+// is moved, the local link is recreated at the new location and the old one
+// removed, and the entries of the configuration (every file entry of a
+// hard-linked directory included) and the git exclude section are
+// rewritten. All of it is applied as one plan, so a failure leaves the
+// project as it was.
+// With dryRun, the planned actions are printed instead of applied.
+func Move(oldPath, newPath string, dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	oldRel, err := resolveLocalRelPath(oldPath, localDir)
+	if err != nil {
+		return err
+	}
+	newRel, err := resolveLocalRelPath(newPath, localDir)
+	if err != nil {
+		return err
+	}
+
+	// The entry itself, or the file entries of a hard-linked directory
+	var moved []Link
+	for _, link := range config.Links {
+		if link.Path == oldRel || strings.HasPrefix(link.Path, oldRel+string(os.PathSeparator)) {
+			moved = append(moved, link)
+		}
+	}
+	if len(moved) == 0 {
+		for _, link := range config.Links {
+			if strings.HasPrefix(oldRel, link.Path+string(os.PathSeparator)) {
+				return fmt.Errorf("%s is inside the entry %s; move the entry instead", oldRel, link.Path)
+			}
+		}
+		return fmt.Errorf("not a managed entry: %s", oldRel)
+	}
+	if err := checkMoveDestination(oldRel, newRel, localDir, remoteDir); err != nil {
+		return err
+	}
+	for _, link := range moved {
+		status := checkLinkStatus(link, config)
+		if status.State != StateLinked && status.State != StateMissing {
+			return fmt.Errorf("cannot move %s: %s. Run 'lnkr status' and fix the entry first", link.Path, getStatusText(status))
+		}
+	}
+
+	// Rewrite the entries, keeping everything but the path
+	renamed := make([]Link, len(moved))
+	var links []Link
+	for _, link := range config.Links {
+		if i := slices.Index(moved, link); i >= 0 {
+			link.Path = newRel + strings.TrimPrefix(link.Path, oldRel)
+			renamed[i] = link
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Path < links[j].Path
+	})
+	if err := validateLinks(links); err != nil {
+		return fmt.Errorf("cannot move %s to %s: %w", oldRel, newRel, err)
+	}
+
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return err
+	}
+
+	plan := &Plan{}
+	for _, link := range moved {
+		localPath := filepath.Join(localDir, link.Path)
+		remotePath := filepath.Join(remoteDir, link.Path)
+		fi, err := os.Lstat(localPath)
+		if err != nil {
+			continue
+		}
+		if link.Type == LinkTypeHard && fi.IsDir() {
+			actions, err := planUnlinkHardLinkedDir(link.Path, localPath, remotePath, config.ignores)
+			if err != nil {
+				return fmt.Errorf("failed to plan unlinking %s: %w", link.Path, err)
+			}
+			// The emptied directories are removed once everything else is done
+			plan.add(slices.DeleteFunc(actions, func(a Action) bool { return a.Kind == ActionRmdir })...)
+			continue
+		}
+		plan.add(Action{Kind: ActionUnlink, Entry: link.Path, Source: remotePath, Target: localPath, LinkType: link.Type, Relative: isRelativeSymlink(localPath)})
+	}
+
+	remoteOld := filepath.Join(remoteDir, oldRel)
+	remoteNew := filepath.Join(remoteDir, newRel)
+	if parent := filepath.Dir(remoteNew); !pathExists(parent) {
+		plan.add(Action{Kind: ActionMkdir, Entry: oldRel, Target: parent})
+	}
+	plan.add(Action{Kind: ActionMove, Entry: oldRel, Source: remoteOld, Target: remoteNew})
+
+	// The new links are planned by hand since the remote paths they link to
+	// only exist once the move has been applied
+	planned := make(map[string]bool)
+	for i, link := range renamed {
+		localPath := filepath.Join(localDir, link.Path)
+		if parent := filepath.Dir(localPath); !planned[parent] && !pathExists(parent) {
+			plan.add(Action{Kind: ActionMkdir, Entry: link.Path, Target: parent})
+			planned[parent] = true
+		}
+		if link.Type == LinkTypeHard {
+			if info, err := os.Stat(filepath.Join(remoteDir, moved[i].Path)); err == nil && info.IsDir() {
+				actions, err := planMovedHardLinkedDir(link.Path, filepath.Join(remoteDir, moved[i].Path), remoteOld, remoteNew, localPath, config.ignores)
+				if err != nil {
+					return err
+				}
+				plan.add(actions...)
+				continue
+			}
+		}
+		plan.add(Action{
+			Kind:         ActionLink,
+			Entry:        link.Path,
+			Source:       filepath.Join(remoteDir, link.Path),
+			Target:       localPath,
+			LinkType:     link.Type,
+			Relative:     config.relativeSymlink(link),
+			CopyFallback: config.copyFallback(),
+			Ignore:       config.ignores,
+		})
+	}
+	for i, link := range moved {
+		if hash, ok := state.Copies[link.Path]; ok {
+			plan.add(recordAction(state, renamed[i].Path, hash), recordAction(state, link.Path, ""))
+		}
+	}
+
+	cfg := configAction(config, links)
+	plan.add(cfg, excludeAction(cfg.Config, config))
+
+	// Clean up the directories left empty at the old location
+	if parent := filepath.Dir(remoteOld); parent != remoteDir {
+		plan.add(Action{Kind: ActionRmdir, Entry: oldRel, Target: parent, Root: remoteDir})
+	}
+	localOld := filepath.Join(localDir, oldRel)
+	if fi, err := os.Lstat(localOld); err == nil && fi.IsDir() {
+		plan.add(Action{Kind: ActionRmdir, Entry: oldRel, Target: localOld})
+	}
+	if parent := filepath.Dir(localOld); parent != localDir {
+		plan.add(Action{Kind: ActionRmdir, Entry: oldRel, Target: parent, Root: localDir})
+	}
+	plan.journal(config, "mv")
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d entr(ies) would be moved.\n", len(moved))
+		return nil
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	for i, link := range moved {
+		fmt.Printf("Moved entry: %s -> %s\n", link.Path, renamed[i].Path)
+	}
+	return nil
+}
+
+// checkMoveDestination checks that newRel is free both locally and in
+// remote and is not inside oldRel.
+func checkMoveDestination(oldRel, newRel, localDir, remoteDir string) error {
+	if newRel == oldRel {
+		return fmt.Errorf("source and destination are the same: %s", oldRel)
+	}
+	if strings.HasPrefix(newRel, oldRel+string(os.PathSeparator)) {
+		return fmt.Errorf("cannot move %s inside itself: %s", oldRel, newRel)
+	}
+	if p := filepath.Join(localDir, newRel); pathExists(p) {
+		return fmt.Errorf("destination already exists: %s", p)
+	}
+	if p := filepath.Join(remoteDir, newRel); pathExists(p) {
+		return fmt.Errorf("destination already exists in remote: %s", p)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, oldRel)); err != nil {
+		return fmt.Errorf("remote path does not exist: %s", filepath.Join(remoteDir, oldRel))
+	}
+	return nil
+}
+
+// planMovedHardLinkedDir plans the hard links of a directory entry recorded
+// as a whole once remoteOld has been moved to remoteNew: the files are found
+// in the directory before the move, at sourceDir, and linked from their
+// place after it.
+func planMovedHardLinkedDir(entry, sourceDir, remoteOld, remoteNew, targetDir string, ignore *ignoreMatcher) ([]Action, error) {
+	conflict := func(_, _ string, err error) (conflictResolution, error) {
+		return conflictResolution{}, err
+	}
+	actions, err := planHardLinksRecursively(entry, sourceDir, targetDir, ignore, conflict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan hard links for directory: %w", err)
+	}
+	for i := range actions {
+		if actions[i].Kind == ActionLink {
+			actions[i].Source = remoteNew + strings.TrimPrefix(actions[i].Source, remoteOld)
+		}
+	}
+	return actions, nil
+}
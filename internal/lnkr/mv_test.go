@@ -0,0 +1,210 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	testCases := []struct {
+		name        string
+		remoteFiles map[string]string
+		localFiles  map[string]string // plain local files created without linking
+		links       []Link
+		oldPath     string
+		newPath     string
+		wantErr     bool
+		wantLinks   []Link
+		wantGone    []string // paths expected to be gone both locally and in remote
+	}{
+		{
+			name:        "SymbolicFile",
+			remoteFiles: map[string]string{"a.txt": "a"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			oldPath:     "a.txt",
+			newPath:     "b.txt",
+			wantLinks:   []Link{{Path: "b.txt", Type: LinkTypeSymbolic}},
+			wantGone:    []string{"a.txt"},
+		},
+		{
+			name:        "HardFileIntoNewDirectory",
+			remoteFiles: map[string]string{"sub/a.txt": "a"},
+			links:       []Link{{Path: "sub/a.txt", Type: LinkTypeHard}},
+			oldPath:     "sub/a.txt",
+			newPath:     "other/dir/a.txt",
+			wantLinks:   []Link{{Path: "other/dir/a.txt", Type: LinkTypeHard}},
+			wantGone:    []string{"sub"},
+		},
+		{
+			name: "HardLinkedDirectoryRewritesChildEntries",
+			remoteFiles: map[string]string{
+				"conf/a.txt":     "a",
+				"conf/sub/b.txt": "b",
+				"keep.txt":       "k",
+			},
+			links: []Link{
+				{Path: "conf/a.txt", Type: LinkTypeHard},
+				{Path: "conf/sub/b.txt", Type: LinkTypeHard},
+				{Path: "keep.txt", Type: LinkTypeSymbolic},
+			},
+			oldPath: "conf",
+			newPath: "settings",
+			wantLinks: []Link{
+				{Path: "keep.txt", Type: LinkTypeSymbolic},
+				{Path: "settings/a.txt", Type: LinkTypeHard},
+				{Path: "settings/sub/b.txt", Type: LinkTypeHard},
+			},
+			wantGone: []string{"conf"},
+		},
+		{
+			name:        "DestinationExistsLocally",
+			remoteFiles: map[string]string{"a.txt": "a"},
+			localFiles:  map[string]string{"b.txt": "local"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			oldPath:     "a.txt",
+			newPath:     "b.txt",
+			wantErr:     true,
+		},
+		{
+			name:        "DestinationExistsInRemote",
+			remoteFiles: map[string]string{"a.txt": "a", "b.txt": "b"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			oldPath:     "a.txt",
+			newPath:     "b.txt",
+			wantErr:     true,
+		},
+		{
+			name:        "DestinationInsideAnotherEntry",
+			remoteFiles: map[string]string{"a.txt": "a", "dir/x.txt": "x"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeSymbolic}, {Path: "dir", Type: LinkTypeSymbolic}},
+			oldPath:     "a.txt",
+			newPath:     "dir/a.txt",
+			wantErr:     true,
+		},
+		{
+			name:        "NotManaged",
+			remoteFiles: map[string]string{"a.txt": "a"},
+			links:       []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			oldPath:     "other.txt",
+			newPath:     "b.txt",
+			wantErr:     true,
+		},
+		{
+			name:        "InsideEntry",
+			remoteFiles: map[string]string{"dir/x.txt": "x"},
+			links:       []Link{{Path: "dir", Type: LinkTypeSymbolic}},
+			oldPath:     "dir/x.txt",
+			newPath:     "x.txt",
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: tc.links})
+			writeFiles(t, remoteDir, tc.remoteFiles)
+			writeFiles(t, localDir, tc.localFiles)
+			if err := CreateLinks(Selector{}, false, false, ""); err != nil {
+				t.Fatalf("failed to create links: %v", err)
+			}
+
+			err := Move(tc.oldPath, tc.newPath, false)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				config, err := loadConfig()
+				if err != nil {
+					t.Fatalf("failed to reload config: %v", err)
+				}
+				if !slices.Equal(config.Links, tc.links) {
+					t.Fatalf("links changed on error: got %+v, want %+v", config.Links, tc.links)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to reload config: %v", err)
+			}
+			if !slices.Equal(config.Links, tc.wantLinks) {
+				t.Fatalf("unexpected links: got %+v, want %+v", config.Links, tc.wantLinks)
+			}
+			for _, link := range tc.wantLinks {
+				assertLink(t, filepath.Join(localDir, link.Path), filepath.Join(remoteDir, link.Path), link.Type)
+			}
+			for _, path := range tc.wantGone {
+				for _, dir := range []string{localDir, remoteDir} {
+					if _, err := os.Lstat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+						t.Fatalf("%s still exists in %s", path, dir)
+					}
+				}
+			}
+
+			entries := gitExcludeSectionEntries(t, GitExcludePath)
+			for _, link := range tc.wantLinks {
+				if !slices.Contains(entries, "/"+link.Path) {
+					t.Fatalf("git exclude does not contain /%s: %v", link.Path, entries)
+				}
+			}
+			for _, link := range tc.links {
+				if !slices.ContainsFunc(tc.wantLinks, func(l Link) bool { return l.Path == link.Path }) && slices.Contains(entries, "/"+link.Path) {
+					t.Fatalf("git exclude still contains /%s: %v", link.Path, entries)
+				}
+			}
+		})
+	}
+}
+
+func TestMoveCopyKeepsSyncState(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeCopy}}})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+	if err := CreateLinks(Selector{}, false, false, ""); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+
+	if err := Move("a.txt", "b.txt", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(localDir, "b.txt"))
+	if err != nil || string(content) != "a" {
+		t.Fatalf("unexpected local copy: %q, %v", content, err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		t.Fatalf("failed to load sync state: %v", err)
+	}
+	if _, ok := state.Copies["a.txt"]; ok {
+		t.Fatalf("sync state still records a.txt: %v", state.Copies)
+	}
+	if status := checkLinkStatus(Link{Path: "b.txt", Type: LinkTypeCopy}, config); status.State != StateLinked {
+		t.Fatalf("unexpected state of b.txt: %s", status.State)
+	}
+}
+
+func TestMoveDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+	if err := CreateLinks(Selector{}, false, false, ""); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+
+	if err := Move("a.txt", "b.txt", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertLink(t, filepath.Join(localDir, "a.txt"), filepath.Join(remoteDir, "a.txt"), LinkTypeSymbolic)
+	if _, err := os.Lstat(filepath.Join(remoteDir, "b.txt")); !os.IsNotExist(err) {
+		t.Fatalf("dry run moved the remote file")
+	}
+}
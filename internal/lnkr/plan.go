@@ -0,0 +1,466 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ActionKind identifies the kind of change an Action makes.
+type ActionKind string
+
+// Action kinds
+const (
+	ActionMove    ActionKind = "move"
+	ActionLink    ActionKind = "link"
+	ActionUnlink  ActionKind = "unlink"
+	ActionMkdir   ActionKind = "mkdir"
+	ActionRmdir   ActionKind = "rmdir"
+	ActionConfig  ActionKind = "config"
+	ActionExclude ActionKind = "exclude"
+	ActionReplace ActionKind = "replace"
+	ActionRecord  ActionKind = "record"
+	ActionRemove  ActionKind = "remove"
+)
+
+// Action is a single step of a Plan.
+type Action struct {
+	Kind ActionKind
+	// Entry is the configuration entry the action belongs to. It is empty
+	// for project-wide actions such as config and exclude edits.
+	Entry string
+	// Source is the remote path for link, unlink and move actions, and the
+	// path copied from by replace actions.
+	Source string
+	// Target is the path the action creates, moves to or removes.
+	Target string
+	// LinkType is the link type for link and unlink actions, and for
+	// replace actions of reflink entries, which clone instead of copying.
+	LinkType string
+	// Relative makes symbolic link actions write the target relative to
+	// the link's directory.
+	Relative bool
+	// CopyFallback makes reflink actions copy the data when the filesystem
+	// cannot clone it.
+	CopyFallback bool
+	// Ignore leaves the ignored paths of Entry out of the copies made by
+	// copy, reflink and replace actions.
+	Ignore *ignoreMatcher
+	// Mode is the permission used by mkdir (0755 when zero).
+	Mode os.FileMode
+	// Root limits rmdir: when set, Target and its empty parents up to (but
+	// not including) Root are removed. When empty, the empty directories of
+	// the tree at Target are removed bottom-up.
+	Root string
+	// Config is the configuration written by config and exclude actions.
+	// Nil removes the configuration file at Target (config) or the LNKR
+	// section of the exclude file at Target (exclude).
+	Config *Config
+	// Prev is the configuration before the change, used to revert it.
+	Prev *Config
+	// Legacy makes an exclude removal also drop the plain configuration
+	// file entry written by old versions.
+	Legacy bool
+	// Hash is the synced content of Entry written by record actions to the
+	// sync state file at Target. Empty forgets the entry.
+	Hash string
+	// PrevHash is the recorded hash before the change, used to revert it.
+	PrevHash string
+	// Optional actions only print a warning when they fail.
+	Optional bool
+}
+
+// String describes the action in the imperative, e.g. "move: a -> b".
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionMove:
+		return fmt.Sprintf("move: %s -> %s", a.Source, a.Target)
+	case ActionLink:
+		return fmt.Sprintf("create %s link: %s -> %s", linkTypeName(a.LinkType), a.Target, a.linkSource())
+	case ActionUnlink:
+		return fmt.Sprintf("remove %s link: %s", linkTypeName(a.LinkType), a.Target)
+	case ActionMkdir:
+		return fmt.Sprintf("create directory: %s", a.Target)
+	case ActionRmdir:
+		return fmt.Sprintf("remove empty directories: %s", a.Target)
+	case ActionConfig:
+		if a.Config == nil {
+			return fmt.Sprintf("remove %s", a.Target)
+		}
+		return fmt.Sprintf("update %s (%d link(s))", a.Target, len(a.Config.Links))
+	case ActionExclude:
+		if a.Config == nil {
+			return fmt.Sprintf("remove LNKR entries from %s", a.Target)
+		}
+		return fmt.Sprintf("update LNKR section in %s", a.Target)
+	case ActionReplace:
+		return fmt.Sprintf("replace with copy: %s -> %s", a.Source, a.Target)
+	case ActionRemove:
+		return fmt.Sprintf("remove: %s", a.Target)
+	case ActionRecord:
+		if a.Hash == "" {
+			return fmt.Sprintf("forget sync state: %s", a.Entry)
+		}
+		return fmt.Sprintf("record sync state: %s", a.Entry)
+	default:
+		return fmt.Sprintf("%s: %s", a.Kind, a.Target)
+	}
+}
+
+// linkTypeName returns the human-readable name of a link type.
+func linkTypeName(linkType string) string {
+	switch linkType {
+	case LinkTypeSymbolic:
+		return "symbolic"
+	case LinkTypeHard:
+		return "hard"
+	case LinkTypeCopy:
+		return "copy"
+	case LinkTypeReflink:
+		return "reflink"
+	default:
+		return linkType
+	}
+}
+
+// Plan is the ordered list of actions a command performs. Commands compute
+// the whole plan before touching anything; --dry-run prints it and a normal
+// run applies it, so the preview always matches what is executed.
+type Plan struct {
+	Actions []Action
+
+	// operation and journalPath enable the journal written while the plan
+	// is applied, so an interrupted run can be recovered.
+	operation   string
+	journalPath string
+}
+
+func (p *Plan) add(actions ...Action) {
+	p.Actions = append(p.Actions, actions...)
+}
+
+// journal makes Apply and ApplyEach record their progress in the journal
+// next to the configuration file under the given operation name.
+func (p *Plan) journal(config *Config, operation string) {
+	p.operation = operation
+	p.journalPath = config.journalPath()
+}
+
+// count returns the number of actions of the given kind.
+func (p *Plan) count(kind ActionKind) int {
+	var n int
+	for _, a := range p.Actions {
+		if a.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Print prints every action of the plan prefixed with "Would", followed by
+// any problem the preflight checks of Apply would report.
+func (p *Plan) Print() {
+	for _, a := range p.Actions {
+		if a.Kind == ActionMove && crossDevice(a.Source, a.Target) {
+			fmt.Printf("Would %s (copy across filesystems)\n", a)
+			continue
+		}
+		fmt.Printf("Would %s\n", a)
+	}
+	if err := preflightMoves(p.Actions); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// Apply executes the actions in order. When a required action fails, the
+// actions already applied are reverted in reverse order and the error is
+// returned, so the plan either completes or leaves things as they were.
+func (p *Plan) Apply() error {
+	if err := preflightMoves(p.Actions); err != nil {
+		return err
+	}
+
+	j, err := beginJournal(p.journalPath, p.operation, p.Actions)
+	if err != nil {
+		return err
+	}
+
+	done := make([]bool, len(p.Actions))
+	for i, a := range p.Actions {
+		err := a.apply()
+		if err == nil {
+			done[i] = true
+			err = j.mark(i, true)
+		}
+		if err != nil {
+			if a.Optional && !done[i] {
+				fmt.Printf("Warning: failed to %s: %v\n", a, err)
+				continue
+			}
+			if !revertActions(p.Actions, done, j) {
+				j.close()
+				return fmt.Errorf("%w; some changes could not be reverted, run 'lnkr recover' to finish", err)
+			}
+			if finishErr := j.finish(); finishErr != nil {
+				fmt.Printf("Warning: %v\n", finishErr)
+			}
+			return err
+		}
+	}
+	return j.finish()
+}
+
+// ApplyEach executes the actions in order, treating every entry
+// independently: a failed action skips the remaining actions of the same
+// entry and reverts the ones already applied, so each entry either
+// completes or is left as it was. The failure is reported through onError
+// and does not stop the others. Project-wide actions are always attempted.
+// It returns the number of entries that failed.
+func (p *Plan) ApplyEach(onError func(entry string, err error)) (int, error) {
+	j, err := beginJournal(p.journalPath, p.operation, p.Actions)
+	if err != nil {
+		return 0, err
+	}
+
+	failed := make(map[string]struct{})
+	done := make([]bool, len(p.Actions))
+	reverted := true
+	for i, a := range p.Actions {
+		if _, ok := failed[a.Entry]; ok && a.Entry != "" {
+			continue
+		}
+		if err := a.apply(); err != nil {
+			if a.Optional || a.Entry == "" {
+				fmt.Printf("Warning: failed to %s: %v\n", a, err)
+				continue
+			}
+			failed[a.Entry] = struct{}{}
+			entryDone := make([]bool, len(p.Actions))
+			for k := range i {
+				entryDone[k] = done[k] && p.Actions[k].Entry == a.Entry
+			}
+			if !revertActions(p.Actions, entryDone, j) {
+				reverted = false
+				err = fmt.Errorf("%w; some changes could not be reverted, run 'lnkr recover' to finish", err)
+			}
+			onError(a.Entry, err)
+			continue
+		}
+		done[i] = true
+		if err := j.mark(i, true); err != nil {
+			// The progress on disk is no longer recorded, so the journal
+			// is kept for 'lnkr recover' to sort out
+			j.close()
+			return len(failed), fmt.Errorf("%w; run 'lnkr recover' to finish", err)
+		}
+	}
+	if !reverted {
+		// The journal is kept so 'lnkr recover' can finish the job
+		j.close()
+		return len(failed), nil
+	}
+	return len(failed), j.finish()
+}
+
+// revertActions reverts the done actions in reverse order, recording each
+// in the journal. Failures are reported as warnings since the original error
+// is what matters; it reports whether everything was reverted.
+func revertActions(actions []Action, done []bool, j *journal) bool {
+	ok := true
+	for i := len(actions) - 1; i >= 0; i-- {
+		if !done[i] {
+			continue
+		}
+		if err := actions[i].revert(); err != nil {
+			fmt.Printf("Warning: failed to revert %s: %v\n", actions[i], err)
+			ok = false
+			continue
+		}
+		if err := j.mark(i, false); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (a Action) apply() error {
+	switch a.Kind {
+	case ActionMove:
+		if err := movePath(a.Source, a.Target); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", a.Source, a.Target, err)
+		}
+		fmt.Printf("Moved: %s -> %s\n", a.Source, a.Target)
+	case ActionLink:
+		if isCopyType(a.LinkType) {
+			return createCopy(a.Source, a.Target, a.LinkType, a.CopyFallback, a.Entry, a.Ignore)
+		}
+		return createLink(a.linkSource(), a.Target, a.LinkType, a.CopyFallback)
+	case ActionUnlink:
+		remove := os.Remove
+		if isCopyType(a.LinkType) {
+			remove = os.RemoveAll
+		}
+		if err := remove(a.Target); err != nil {
+			return fmt.Errorf("failed to remove %s link: %w", linkTypeName(a.LinkType), err)
+		}
+		fmt.Printf("Removed %s link: %s\n", linkTypeName(a.LinkType), a.Target)
+	case ActionMkdir:
+		mode := a.Mode
+		if mode == 0 {
+			mode = 0755
+		}
+		if err := os.MkdirAll(a.Target, mode); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", a.Target, err)
+		}
+	case ActionRmdir:
+		if a.Root != "" {
+			cleanEmptyDirs(a.Target, a.Root)
+		} else {
+			removeEmptyDirTree(a.Target)
+		}
+	case ActionConfig:
+		if a.Config == nil {
+			if err := removeLnkToml(a.Target); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", a.Target, err)
+			}
+			return nil
+		}
+		if err := saveConfig(a.Config); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	case ActionExclude:
+		if a.Config != nil {
+			return applyAllLinksToGitExclude(a.Config)
+		}
+		removed, err := removeGitExcludeSection(a.Target)
+		if err != nil {
+			return fmt.Errorf("failed to remove LNKR section from %s: %w", a.Target, err)
+		}
+		if removed {
+			fmt.Printf("Removed LNKR section from %s\n", a.Target)
+		}
+		if a.Legacy {
+			if err := removeFromGitExcludeWithPath(a.Target, ConfigFileName); err != nil {
+				return fmt.Errorf("failed to remove from %s: %w", a.Target, err)
+			}
+		}
+	case ActionReplace:
+		if err := replaceWithCopy(a.Source, a.Target, a.cloneMode(), a.Entry, a.Ignore); err != nil {
+			return err
+		}
+		fmt.Printf("Copied: %s -> %s\n", a.Source, a.Target)
+	case ActionRecord:
+		state, err := loadSyncState(a.Target)
+		if err != nil {
+			return err
+		}
+		return state.record(a.Entry, a.Hash)
+	case ActionRemove:
+		if err := os.RemoveAll(a.Target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", a.Target, err)
+		}
+		fmt.Printf("Removed: %s\n", a.Target)
+	default:
+		return fmt.Errorf("unknown action: %s", a.Kind)
+	}
+	return nil
+}
+
+// linkSource returns what link actions link to: the source, or for
+// relative symbolic links the source relative to the link's directory.
+func (a Action) linkSource() string {
+	if a.Relative && a.LinkType == LinkTypeSymbolic {
+		return relativeLinkTarget(a.Source, a.Target)
+	}
+	return a.Source
+}
+
+// cloneMode returns how replace actions copy file data.
+func (a Action) cloneMode() cloneMode {
+	switch {
+	case a.LinkType != LinkTypeReflink:
+		return cloneNever
+	case a.CopyFallback:
+		return cloneOrCopy
+	default:
+		return cloneOnly
+	}
+}
+
+// inverse returns the action undoing a, or false when a cannot be undone
+// (removing empty directories or content, replacing content) or needs no
+// undoing.
+func (a Action) inverse() (Action, bool) {
+	inv := a
+	switch a.Kind {
+	case ActionMove:
+		inv.Source, inv.Target = a.Target, a.Source
+	case ActionLink:
+		inv.Kind = ActionUnlink
+	case ActionUnlink:
+		inv.Kind = ActionLink
+	case ActionMkdir:
+		inv.Kind = ActionRmdir
+		inv.Root = ""
+	case ActionConfig, ActionExclude:
+		if a.Prev == nil {
+			return Action{}, false
+		}
+		inv.Config, inv.Prev = a.Prev, a.Config
+	case ActionRecord:
+		inv.Hash, inv.PrevHash = a.PrevHash, a.Hash
+	default:
+		return Action{}, false
+	}
+	return inv, true
+}
+
+// revert undoes an applied action as far as possible.
+func (a Action) revert() error {
+	inv, ok := a.inverse()
+	if !ok {
+		return nil
+	}
+	if inv.Kind == ActionMove {
+		if err := os.MkdirAll(filepath.Dir(inv.Target), 0755); err != nil {
+			return err
+		}
+	}
+	return inv.apply()
+}
+
+// applied inspects the filesystem to tell whether a has taken effect. The
+// second result is false for actions whose effect cannot be observed; those
+// are safe to apply or revert again.
+func (a Action) applied() (bool, bool) {
+	switch a.Kind {
+	case ActionMove:
+		return pathExists(a.Target) && !pathExists(a.Source), true
+	case ActionLink, ActionMkdir:
+		return pathExists(a.Target), true
+	case ActionUnlink, ActionRemove:
+		return !pathExists(a.Target), true
+	default:
+		return false, false
+	}
+}
+
+// pathExists reports whether path exists without following a final symlink.
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// configAction returns an action saving config with its links replaced.
+func configAction(config *Config, links []Link) Action {
+	updated := *config
+	updated.Links = links
+	return Action{Kind: ActionConfig, Target: config.path(), Config: &updated, Prev: config}
+}
+
+// excludeAction returns an optional action rewriting the LNKR section of
+// the git exclude file from config's links.
+func excludeAction(config *Config, prev *Config) Action {
+	return Action{Kind: ActionExclude, Target: config.GetGitExcludePath(), Config: config, Prev: prev, Optional: true}
+}
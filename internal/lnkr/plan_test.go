@@ -0,0 +1,131 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestActionString(t *testing.T) {
+	testCases := []struct {
+		name   string
+		action Action
+		want   string
+	}{
+		{
+			name:   "Move",
+			action: Action{Kind: ActionMove, Source: "/l/a", Target: "/r/a"},
+			want:   "move: /l/a -> /r/a",
+		},
+		{
+			name:   "SymbolicLink",
+			action: Action{Kind: ActionLink, Source: "/r/a", Target: "/l/a", LinkType: LinkTypeSymbolic},
+			want:   "create symbolic link: /l/a -> /r/a",
+		},
+		{
+			name:   "HardUnlink",
+			action: Action{Kind: ActionUnlink, Source: "/r/a", Target: "/l/a", LinkType: LinkTypeHard},
+			want:   "remove hard link: /l/a",
+		},
+		{
+			name:   "RemoveConfig",
+			action: Action{Kind: ActionConfig, Target: ConfigFileName},
+			want:   "remove " + ConfigFileName,
+		},
+		{
+			name:   "UpdateExclude",
+			action: Action{Kind: ActionExclude, Target: GitExcludePath, Config: &Config{}},
+			want:   "update LNKR section in " + GitExcludePath,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.action.String(); got != tc.want {
+				t.Fatalf("String() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPlanApplyRevertsOnFailure(t *testing.T) {
+	localDir, remoteDir := setupProject(t, nil)
+	writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+
+	localPath := filepath.Join(localDir, "a.txt")
+	remotePath := filepath.Join(remoteDir, "a.txt")
+	plan := &Plan{}
+	plan.add(
+		Action{Kind: ActionMove, Entry: "a.txt", Source: localPath, Target: remotePath},
+		Action{Kind: ActionLink, Entry: "a.txt", Source: remotePath, Target: localPath, LinkType: LinkTypeSymbolic},
+		// Fails: the parent of the target does not exist.
+		Action{Kind: ActionLink, Entry: "b.txt", Source: remotePath, Target: filepath.Join(localDir, "missing", "b.txt"), LinkType: LinkTypeSymbolic},
+	)
+
+	if err := plan.Apply(); err == nil {
+		t.Fatalf("expected error but got none")
+	}
+
+	// The move and the first link must be reverted.
+	fi, err := os.Lstat(localPath)
+	if err != nil {
+		t.Fatalf("local file missing after revert: %v", err)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("link was not reverted: %s", localPath)
+	}
+	if _, err := os.Stat(remotePath); !os.IsNotExist(err) {
+		t.Fatalf("move was not reverted: %s still exists", remotePath)
+	}
+}
+
+func TestPlanApplyEach(t *testing.T) {
+	localDir, remoteDir := setupProject(t, nil)
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	plan := &Plan{}
+	plan.add(
+		Action{Kind: ActionLink, Entry: "missing/a.txt", Source: filepath.Join(remoteDir, "a.txt"), Target: filepath.Join(localDir, "missing", "a.txt"), LinkType: LinkTypeSymbolic},
+		Action{Kind: ActionLink, Entry: "b.txt", Source: filepath.Join(remoteDir, "b.txt"), Target: filepath.Join(localDir, "b.txt"), LinkType: LinkTypeSymbolic},
+	)
+
+	var failedEntries []string
+	failed, err := plan.ApplyEach(func(entry string, err error) {
+		failedEntries = append(failedEntries, entry)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failed != 1 || len(failedEntries) != 1 || failedEntries[0] != "missing/a.txt" {
+		t.Fatalf("unexpected failures: %d %v", failed, failedEntries)
+	}
+
+	// The other entry must still be applied.
+	assertLink(t, filepath.Join(localDir, "b.txt"), filepath.Join(remoteDir, "b.txt"), LinkTypeSymbolic)
+}
+
+func TestPlanAddMatchesApply(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a"})
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	plan := planAdd(config, []string{"conf/a.txt"}, localDir, remoteDir, LinkTypeSymbolic)
+
+	var kinds []string
+	for _, a := range plan.Actions {
+		kinds = append(kinds, string(a.Kind))
+	}
+	want := "mkdir move link config exclude"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("unexpected plan: got %q, want %q", got, want)
+	}
+
+	// Planning must not change the loaded configuration.
+	if len(config.Links) != 0 {
+		t.Fatalf("planning modified the configuration: %+v", config.Links)
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"strings"
 )
 
+// stdin is shared by all prompts so answers piped in on several lines are
+// not lost to the buffer of an earlier prompt.
+var stdin = bufio.NewReader(os.Stdin)
+
 // confirm asks a yes/no question on stdin and returns true only for an
 // explicit yes. Any read error (e.g. closed stdin) is treated as no.
 func confirm(prompt string) bool {
 	fmt.Printf("%s [y/N]: ", prompt)
-	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	line, err := stdin.ReadString('\n')
 	if err != nil {
 		fmt.Println()
 		return false
@@ -19,3 +23,22 @@ func confirm(prompt string) bool {
 	answer := strings.ToLower(strings.TrimSpace(line))
 	return answer == "y" || answer == "yes"
 }
+
+// choose asks a question on stdin and returns the option whose first letter
+// or full name was answered. An empty or unknown answer, or a read error,
+// returns the first option.
+func choose(prompt string, options ...string) string {
+	fmt.Printf("%s [%s]: ", prompt, strings.Join(options, "/"))
+	line, err := stdin.ReadString('\n')
+	if err != nil {
+		fmt.Println()
+		return options[0]
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	for _, option := range options {
+		if answer != "" && (answer == option || answer == option[:1]) {
+			return option
+		}
+	}
+	return options[0]
+}
@@ -0,0 +1,165 @@
+package lnkr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Recovery directions
+const (
+	RecoverForward  = "forward"
+	RecoverRollback = "rollback"
+)
+
+// Recover finishes or undoes an operation that was interrupted while it was
+// applied, based on its journal. With an empty direction it only describes
+// the interrupted operation. With dryRun, the recovery steps are printed
+// instead of applied.
+func Recover(direction string, dryRun bool) error {
+	if direction != "" && direction != RecoverForward && direction != RecoverRollback {
+		return fmt.Errorf("invalid recovery direction: %s. Must be '%s' or '%s'", direction, RecoverForward, RecoverRollback)
+	}
+
+	path, err := findJournal()
+	if err != nil {
+		return err
+	}
+	state, err := readJournal(path)
+	if os.IsNotExist(err) {
+		fmt.Println("No interrupted operation found.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var completed int
+	for _, done := range state.Done {
+		if done {
+			completed++
+		}
+	}
+	fmt.Printf("Interrupted operation: %s (started %s)\n", state.Operation, state.Started.Format("2006-01-02 15:04:05"))
+	fmt.Printf("%d of %d action(s) completed\n", completed, len(state.Actions))
+
+	if direction == "" {
+		for i, a := range state.Actions {
+			mark := " "
+			if state.Done[i] {
+				mark = "x"
+			}
+			fmt.Printf("  [%s] %s\n", mark, a)
+		}
+		fmt.Printf("Run 'lnkr recover --%s' to complete it or 'lnkr recover --%s' to undo it.\n", RecoverForward, RecoverRollback)
+		return nil
+	}
+
+	steps := planRecovery(state, direction)
+	if dryRun {
+		for _, step := range steps {
+			fmt.Printf("Would %s\n", step.describe())
+		}
+		fmt.Printf("Dry run: %d recovery step(s) would be applied.\n", len(steps))
+		return nil
+	}
+
+	// Recovery progress is journaled too, so recovery can be interrupted
+	// and resumed.
+	j, err := openJournal(path)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			if step.action.Optional {
+				fmt.Printf("Warning: failed to %s: %v\n", step.describe(), err)
+				continue
+			}
+			_ = j.file.Close()
+			return fmt.Errorf("recovery failed: %w; fix the problem and run 'lnkr recover --%s' again", err, direction)
+		}
+		if err := j.mark(step.index, !step.revert); err != nil {
+			_ = j.file.Close()
+			return err
+		}
+	}
+
+	if err := j.finish(); err != nil {
+		return err
+	}
+	fmt.Printf("Recovery completed (%s).\n", direction)
+	return nil
+}
+
+// recoveryStep applies or reverts a single journaled action.
+type recoveryStep struct {
+	index  int
+	action Action
+	revert bool
+}
+
+func (s recoveryStep) describe() string {
+	if !s.revert {
+		return s.action.String()
+	}
+	inv, _ := s.action.inverse()
+	return inv.String()
+}
+
+func (s recoveryStep) run() error {
+	if s.revert {
+		return s.action.revert()
+	}
+	return s.action.apply()
+}
+
+// planRecovery computes the steps rolling the journaled operation forward
+// (applying what is left) or back (reverting what took effect, newest
+// first). The journal is trusted except for the interrupted action, which
+// is checked on the filesystem when possible; it is always the first step
+// to run, so the check is not affected by the other steps.
+func planRecovery(state *journalState, direction string) []recoveryStep {
+	pending := func(i int, wantApplied bool) bool {
+		if i != state.Uncertain {
+			return state.Done[i] == wantApplied
+		}
+		applied, known := state.Actions[i].applied()
+		return !known || applied == wantApplied
+	}
+
+	var steps []recoveryStep
+	if direction == RecoverForward {
+		for i, a := range state.Actions {
+			if pending(i, false) {
+				steps = append(steps, recoveryStep{index: i, action: a})
+			}
+		}
+		return steps
+	}
+
+	for i := len(state.Actions) - 1; i >= 0; i-- {
+		a := state.Actions[i]
+		if _, ok := a.inverse(); !ok {
+			continue
+		}
+		if pending(i, true) {
+			steps = append(steps, recoveryStep{index: i, action: a, revert: true})
+		}
+	}
+	return steps
+}
+
+// findJournal returns the journal path next to the configuration file, or
+// in the current directory when there is no configuration file.
+func findJournal() (string, error) {
+	configPath, err := findConfigFile()
+	if err == nil {
+		return filepath.Join(filepath.Dir(configPath), JournalFileName), nil
+	}
+	if !errors.Is(err, ErrConfigNotFound) {
+		return "", err
+	}
+	return JournalFileName, nil
+}
@@ -0,0 +1,140 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// interruptAdd applies the first n actions of an add plan with a journal and
+// stops as if the process was killed.
+func interruptAdd(t *testing.T, localDir, remoteDir string, targets []string, n int) {
+	t.Helper()
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	plan := planAdd(config, targets, localDir, remoteDir, LinkTypeSymbolic)
+	j, err := beginJournal(config.journalPath(), "add", plan.Actions)
+	if err != nil {
+		t.Fatalf("failed to begin journal: %v", err)
+	}
+	t.Cleanup(func() { _ = j.file.Close() })
+	for i := range n {
+		if err := plan.Actions[i].apply(); err != nil {
+			t.Fatalf("failed to apply %s: %v", plan.Actions[i], err)
+		}
+		if err := j.mark(i, true); err != nil {
+			t.Fatalf("failed to mark: %v", err)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	targets := []string{"a.txt", "b.txt", "c.txt"}
+
+	testCases := []struct {
+		name      string
+		direction string
+		wantLinks []Link
+	}{
+		{
+			name:      "Forward",
+			direction: RecoverForward,
+			wantLinks: []Link{
+				{Path: "a.txt", Type: LinkTypeSymbolic},
+				{Path: "b.txt", Type: LinkTypeSymbolic},
+				{Path: "c.txt", Type: LinkTypeSymbolic},
+			},
+		},
+		{
+			name:      "Rollback",
+			direction: RecoverRollback,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+			writeFiles(t, localDir, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+
+			// a.txt is moved and linked, b.txt is moved but not linked yet.
+			interruptAdd(t, localDir, remoteDir, targets, 3)
+
+			// Showing the interrupted operation changes nothing.
+			if err := Recover("", false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := os.Stat(JournalFileName); err != nil {
+				t.Fatalf("journal removed without recovery: %v", err)
+			}
+
+			if err := Recover(tc.direction, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to reload config: %v", err)
+			}
+			if !slices.Equal(config.Links, tc.wantLinks) {
+				t.Fatalf("unexpected links: got %+v, want %+v", config.Links, tc.wantLinks)
+			}
+			if _, err := os.Stat(JournalFileName); !os.IsNotExist(err) {
+				t.Fatalf("journal still exists after recovery")
+			}
+
+			for _, target := range targets {
+				localPath := filepath.Join(localDir, target)
+				if tc.direction == RecoverForward {
+					assertLink(t, localPath, filepath.Join(remoteDir, target), LinkTypeSymbolic)
+					continue
+				}
+				fi, err := os.Lstat(localPath)
+				if err != nil {
+					t.Fatalf("local file missing after rollback: %v", err)
+				}
+				if !fi.Mode().IsRegular() {
+					t.Fatalf("expected regular file after rollback: %s", localPath)
+				}
+				if _, err := os.Lstat(filepath.Join(remoteDir, target)); !os.IsNotExist(err) {
+					t.Fatalf("remote file still exists after rollback: %s", target)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+	interruptAdd(t, localDir, remoteDir, []string{"a.txt"}, 1)
+
+	if err := Recover(RecoverRollback, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing must change: the file stays in remote and the journal is kept.
+	if _, err := os.Stat(filepath.Join(remoteDir, "a.txt")); err != nil {
+		t.Fatalf("remote file moved during dry run: %v", err)
+	}
+	if _, err := os.Stat(JournalFileName); err != nil {
+		t.Fatalf("journal removed during dry run: %v", err)
+	}
+}
+
+func TestRecoverNoJournal(t *testing.T) {
+	setupProject(t, &Config{Links: []Link{}})
+
+	if err := Recover(RecoverForward, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecoverInvalidDirection(t *testing.T) {
+	if err := Recover("sideways", false); err == nil {
+		t.Fatalf("expected error but got none")
+	}
+}
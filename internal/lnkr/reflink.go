@@ -0,0 +1,19 @@
+package lnkr
+
+import "errors"
+
+// ErrReflinkUnsupported is returned when the filesystem (or platform) cannot
+// clone files.
+var ErrReflinkUnsupported = errors.New("filesystem does not support reflinks")
+
+// cloneMode selects how file data is copied.
+type cloneMode int
+
+const (
+	// cloneNever copies the data.
+	cloneNever cloneMode = iota
+	// cloneOrCopy clones, copying the data when cloning is unsupported.
+	cloneOrCopy
+	// cloneOnly clones and fails when cloning is unsupported.
+	cloneOnly
+)
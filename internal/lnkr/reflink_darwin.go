@@ -0,0 +1,23 @@
+package lnkr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst as a copy-on-write clone of src with clonefile(2)
+// (APFS). Dst must not exist. Filesystems without support report
+// ErrReflinkUnsupported.
+func cloneFile(src, dst string, perm os.FileMode) error {
+	err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EXDEV) {
+			return fmt.Errorf("%w: %s: %v", ErrReflinkUnsupported, dst, err)
+		}
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package lnkr
+
+import (
+	"fmt"
+	"os"
+)
+
+// cloneFile reports ErrReflinkUnsupported: cloning is not implemented on
+// this platform.
+func cloneFile(src, dst string, perm os.FileMode) error {
+	return fmt.Errorf("%w: %s", ErrReflinkUnsupported, dst)
+}
@@ -0,0 +1,94 @@
+package lnkr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// reflinkSupported reports whether files in dir can be cloned.
+func reflinkSupported(t *testing.T, dir string) bool {
+	t.Helper()
+
+	src := filepath.Join(dir, "probe")
+	if err := os.WriteFile(src, []byte("probe"), 0644); err != nil {
+		t.Fatalf("failed to write probe: %v", err)
+	}
+	defer func() { _ = os.Remove(src) }()
+
+	err := cloneFile(src, src+".clone", 0644)
+	_ = os.Remove(src + ".clone")
+	if err != nil && !errors.Is(err, ErrReflinkUnsupported) {
+		t.Fatalf("unexpected error probing reflink support: %v", err)
+	}
+	return err == nil
+}
+
+func TestCloneFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	err := cloneFile(src, dst, 0600)
+	if errors.Is(err, ErrReflinkUnsupported) {
+		// Negative case (e.g. ext4, tmpfs): nothing may be left behind.
+		if _, statErr := os.Lstat(dst); !os.IsNotExist(statErr) {
+			t.Fatalf("expected no clone to be left behind, got %v", statErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readFile(t, dst); got != "content" {
+		t.Fatalf("unexpected clone content: %q", got)
+	}
+}
+
+func TestAddReflinkFallback(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	if reflinkSupported(t, remoteDir) {
+		t.Skip("filesystem supports reflinks; fallback cannot be exercised")
+	}
+	writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+	localPath := filepath.Join(localDir, "a.txt")
+
+	// Without a fallback the add fails and is reverted.
+	err := Add([]string{localPath}, false, LinkTypeReflink, false)
+	if !errors.Is(err, ErrReflinkUnsupported) {
+		t.Fatalf("expected ErrReflinkUnsupported, got %v", err)
+	}
+	if got := readFile(t, localPath); got != "a" {
+		t.Fatalf("expected local file to be restored, got %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(remoteDir, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected remote file to be moved back, got %v", err)
+	}
+
+	// With reflink_fallback = "copy" the data is copied instead.
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	config.ReflinkFallback = ReflinkFallbackCopy
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	if err := Add([]string{localPath}, false, LinkTypeReflink, false); err != nil {
+		t.Fatalf("unexpected error with copy fallback: %v", err)
+	}
+	if fi, err := os.Lstat(localPath); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected a regular file at local: %v", err)
+	}
+	config, err = loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if status := getStatusText(checkLinkStatus(Link{Path: "a.txt", Type: LinkTypeReflink}, config)); status != SyncInSync {
+		t.Fatalf("unexpected status: %q", status)
+	}
+}
@@ -0,0 +1,174 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Relocate moves the project's remote directory, .lnkr.toml included, to
+// newRemote and updates remote in the configuration. Symbolic links hold
+// the remote path, so every symbolic link of an entry and the .lnkr.toml
+// symlink are pointed at the new location; hard links are checked to still
+// share their file with remote afterwards.
+// With dryRun, the planned actions are printed instead of applied.
+func Relocate(newRemote string, dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if config.Local == "" || config.Remote == "" {
+		return fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	expanded, err := ExpandPath(newRemote)
+	if err != nil {
+		return fmt.Errorf("failed to expand new remote path: %w", err)
+	}
+	newDir, err := filepath.Abs(expanded)
+	if err != nil {
+		return fmt.Errorf("failed to convert new remote to absolute path: %w", err)
+	}
+	if err := checkRelocateDestination(remoteDir, newDir); err != nil {
+		return err
+	}
+
+	// Hard links only survive a rename within the filesystem
+	var hardLinked []Link
+	for _, link := range config.Links {
+		if link.Type == LinkTypeHard && checkLinkStatus(link, config).State == StateLinked {
+			hardLinked = append(hardLinked, link)
+		}
+	}
+	if len(hardLinked) > 0 && crossDevice(remoteDir, newDir) {
+		return fmt.Errorf("cannot relocate %d hard-linked entr(ies) to a different filesystem (%s); run 'lnkr switch --type %s %s' first", len(hardLinked), newDir, LinkTypeHard, LinkTypeSymbolic)
+	}
+
+	plan := &Plan{}
+	if parent := filepath.Dir(newDir); !pathExists(parent) {
+		plan.add(Action{Kind: ActionMkdir, Target: parent})
+	} else if pathExists(newDir) {
+		// An empty directory is replaced by the remote tree
+		plan.add(Action{Kind: ActionRmdir, Target: newDir})
+	}
+	plan.add(Action{Kind: ActionMove, Source: remoteDir, Target: newDir})
+
+	var repointed int
+	for _, link := range config.Links {
+		if link.Type != LinkTypeSymbolic {
+			continue
+		}
+		localPath := filepath.Join(localDir, link.Path)
+		remotePath := filepath.Join(remoteDir, link.Path)
+		if !symlinkTo(localPath, remotePath) {
+			// Missing and wrong links are left to 'lnkr link' and 'lnkr repair'
+			continue
+		}
+		relative := isRelativeSymlink(localPath)
+		plan.add(
+			Action{Kind: ActionUnlink, Entry: link.Path, Source: remotePath, Target: localPath, LinkType: LinkTypeSymbolic, Relative: relative},
+			Action{Kind: ActionLink, Entry: link.Path, Source: filepath.Join(newDir, link.Path), Target: localPath, LinkType: LinkTypeSymbolic, Relative: relative},
+		)
+		repointed++
+	}
+
+	// The configuration is written through the .lnkr.toml symlink, so it is
+	// pointed at the moved file first
+	configPath, err := filepath.Abs(config.path())
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ConfigFileName, err)
+	}
+	if oldConfig := filepath.Join(remoteDir, ConfigFileName); symlinkTo(configPath, oldConfig) {
+		relative := isRelativeSymlink(configPath)
+		plan.add(
+			Action{Kind: ActionUnlink, Source: oldConfig, Target: configPath, LinkType: LinkTypeSymbolic, Relative: relative},
+			Action{Kind: ActionLink, Source: filepath.Join(newDir, ConfigFileName), Target: configPath, LinkType: LinkTypeSymbolic, Relative: relative},
+		)
+	}
+	cfg := configAction(config, config.Links)
+	cfg.Config.Remote = ContractPath(newDir)
+	plan.add(cfg)
+	plan.journal(config, "relocate")
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: remote would be relocated to %s, %d symbolic link(s) re-pointed, %d hard link(s) kept.\n", newDir, repointed, len(hardLinked))
+		return nil
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	fmt.Printf("Relocated remote: %s -> %s\n", remoteDir, newDir)
+
+	updated, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	var broken []string
+	for _, link := range hardLinked {
+		if status := checkLinkStatus(link, updated); status.State != StateLinked {
+			broken = append(broken, fmt.Sprintf("%s: %s", link.Path, getStatusText(status)))
+		}
+	}
+	if len(broken) > 0 {
+		return fmt.Errorf("%d hard link(s) no longer share their file with remote; run 'lnkr repair':\n  %s", len(broken), strings.Join(broken, "\n  "))
+	}
+	if len(hardLinked) > 0 {
+		fmt.Printf("Verified %d hard link(s).\n", len(hardLinked))
+	}
+	return nil
+}
+
+// checkRelocateDestination checks that newDir can take the remote tree at
+// remoteDir: it must be another path outside remoteDir that does not exist
+// or is an empty directory.
+func checkRelocateDestination(remoteDir, newDir string) error {
+	if newDir == remoteDir {
+		return fmt.Errorf("remote is already at %s", newDir)
+	}
+	if _, ok := relPathWithin(remoteDir, newDir); ok {
+		return fmt.Errorf("cannot relocate remote inside itself: %s", newDir)
+	}
+	if info, err := os.Stat(remoteDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("remote directory does not exist: %s", remoteDir)
+	}
+	info, err := os.Lstat(newDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", newDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("destination already exists: %s", newDir)
+	}
+	entries, err := os.ReadDir(newDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", newDir, err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("destination already exists and is not empty: %s", newDir)
+	}
+	return nil
+}
+
+// symlinkTo reports whether path is a symbolic link pointing to want, with
+// an absolute or relative target.
+func symlinkTo(path, want string) bool {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := os.Readlink(path)
+	return err == nil && symlinkPointsTo(path, target, want)
+}
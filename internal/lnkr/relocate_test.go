@@ -0,0 +1,125 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupRelocateProject initializes a project with its configuration in
+// remote, a symbolic link and a hard link, and returns the local and remote
+// directories.
+func setupRelocateProject(t *testing.T) (localDir, remoteDir string) {
+	t.Helper()
+	resetGlobalConfig(t)
+
+	tempDir := t.TempDir()
+	localDir = filepath.Join(tempDir, "project")
+	remoteDir = filepath.Join(tempDir, "remote")
+	writeFiles(t, localDir, map[string]string{"sym.txt": "s", "conf/hard.txt": "h"})
+	t.Chdir(localDir)
+
+	if err := Init(remoteDir, GitExcludePath, false); err != nil {
+		t.Fatalf("failed to init: %v", err)
+	}
+	if err := Add([]string{"sym.txt"}, false, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	if err := Add([]string{"conf"}, true, LinkTypeHard, false); err != nil {
+		t.Fatalf("failed to add: %v", err)
+	}
+	return localDir, remoteDir
+}
+
+func TestRelocate(t *testing.T) {
+	localDir, remoteDir := setupRelocateProject(t)
+	newDir := filepath.Join(filepath.Dir(remoteDir), "moved", "remote")
+
+	if err := Relocate(newDir, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Lstat(remoteDir); !os.IsNotExist(err) {
+		t.Fatalf("old remote directory still exists")
+	}
+	target, err := os.Readlink(filepath.Join(localDir, ConfigFileName))
+	if err != nil {
+		t.Fatalf("failed to read %s link: %v", ConfigFileName, err)
+	}
+	if target != filepath.Join(newDir, ConfigFileName) {
+		t.Fatalf("unexpected %s target: got %q, want %q", ConfigFileName, target, filepath.Join(newDir, ConfigFileName))
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	remoteExpanded, err := config.GetRemoteExpanded()
+	if err != nil {
+		t.Fatalf("failed to expand remote path: %v", err)
+	}
+	if remoteExpanded != newDir {
+		t.Fatalf("unexpected remote: got %q, want %q", remoteExpanded, newDir)
+	}
+	if len(config.Links) != 2 {
+		t.Fatalf("unexpected links: %+v", config.Links)
+	}
+
+	assertLink(t, filepath.Join(localDir, "sym.txt"), filepath.Join(newDir, "sym.txt"), LinkTypeSymbolic)
+	assertLink(t, filepath.Join(localDir, "conf", "hard.txt"), filepath.Join(newDir, "conf", "hard.txt"), LinkTypeHard)
+}
+
+func TestRelocateDryRun(t *testing.T) {
+	localDir, remoteDir := setupRelocateProject(t)
+	newDir := filepath.Join(filepath.Dir(remoteDir), "moved")
+
+	if err := Relocate(newDir, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Lstat(newDir); !os.IsNotExist(err) {
+		t.Fatalf("dry run created the new remote directory")
+	}
+	assertLink(t, filepath.Join(localDir, "sym.txt"), filepath.Join(remoteDir, "sym.txt"), LinkTypeSymbolic)
+	assertLink(t, filepath.Join(localDir, ConfigFileName), filepath.Join(remoteDir, ConfigFileName), LinkTypeSymbolic)
+}
+
+func TestRelocateInvalidDestination(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string // files created under the parent of remote
+		dest  string            // relative to the parent of remote
+	}{
+		{name: "SameDirectory", dest: "remote"},
+		{name: "InsideRemote", dest: "remote/sub"},
+		{name: "NotEmpty", files: map[string]string{"other/x.txt": "x"}, dest: "other"},
+		{name: "File", files: map[string]string{"file": "x"}, dest: "file"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, remoteDir := setupRelocateProject(t)
+			writeFiles(t, filepath.Dir(remoteDir), tc.files)
+
+			if err := Relocate(filepath.Join(filepath.Dir(remoteDir), tc.dest), false); err == nil {
+				t.Fatalf("expected error but got none")
+			}
+			if _, err := os.Stat(filepath.Join(remoteDir, ConfigFileName)); err != nil {
+				t.Fatalf("remote configuration was moved: %v", err)
+			}
+		})
+	}
+}
+
+func TestRelocateIntoEmptyDirectory(t *testing.T) {
+	localDir, remoteDir := setupRelocateProject(t)
+	newDir := filepath.Join(filepath.Dir(remoteDir), "empty")
+	if err := os.MkdirAll(newDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	if err := Relocate(newDir, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertLink(t, filepath.Join(localDir, "sym.txt"), filepath.Join(newDir, "sym.txt"), LinkTypeSymbolic)
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
-	"strings"
 )
 
-// Remove removes a link from the configuration and restores the file from remote to local.
+// Remove removes the links selected by sel from the configuration and restores the files from remote to local.
 // This is the reverse operation of Add.
-func Remove(path string, dryRun bool) error {
+func Remove(sel Selector, dryRun bool) error {
+	if sel.IsEmpty() {
+		return fmt.Errorf("no entries selected; give a path, a pattern or a selector")
+	}
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -26,20 +29,14 @@ func Remove(path string, dryRun bool) error {
 		return fmt.Errorf("failed to expand remote path: %w", err)
 	}
 
-	// Normalize the input path (trailing slash, "./" prefix, CWD-relative)
-	if resolved, err := resolveLocalRelPath(path, localDir); err == nil {
-		path = resolved
-	} else {
-		path = filepath.Clean(path)
-	}
-
 	// Find matching links
-	var linksToRemove []Link
+	linksToRemove, err := selectEntries(config, sel)
+	if err != nil {
+		return err
+	}
 	var newLinks []Link
 	for _, link := range config.Links {
-		if link.Path == path || strings.HasPrefix(link.Path, path+string(os.PathSeparator)) {
-			linksToRemove = append(linksToRemove, link)
-		} else {
+		if !slices.Contains(linksToRemove, link) {
 			newLinks = append(newLinks, link)
 		}
 	}
@@ -49,26 +46,24 @@ func Remove(path string, dryRun bool) error {
 		return nil
 	}
 
-	if dryRun {
-		for _, link := range linksToRemove {
-			fmt.Printf("Would restore: %s -> %s\n", filepath.Join(remoteDir, link.Path), filepath.Join(localDir, link.Path))
-		}
-		fmt.Printf("Dry run: %d link(s) would be removed.\n", len(linksToRemove))
-		return nil
-	}
-
 	// Sort links to remove in reverse order (deepest paths first)
 	// This ensures child files are processed before parent directories
 	sort.Slice(linksToRemove, func(i, j int) bool {
 		return linksToRemove[i].Path > linksToRemove[j].Path
 	})
 
-	// Process each link: remove link, move file from remote to local
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return err
+	}
+
+	plan := &Plan{}
 	for _, link := range linksToRemove {
-		if err := restoreFromRemote(link, localDir, remoteDir); err != nil {
+		actions, err := planRestoreFromRemote(link, localDir, remoteDir, state, config.ignores)
+		if err != nil {
 			return fmt.Errorf("failed to restore %s: %w", link.Path, err)
 		}
-		fmt.Printf("Removed link: %s\n", link.Path)
+		plan.add(actions...)
 	}
 
 	// Sort remaining links
@@ -76,71 +71,84 @@ func Remove(path string, dryRun bool) error {
 		return newLinks[i].Path < newLinks[j].Path
 	})
 
-	config.Links = newLinks
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
-	}
-
 	// Apply all remaining link paths to GitExclude
-	if err := applyAllLinksToGitExclude(config); err != nil {
-		fmt.Printf("Warning: failed to apply link paths to GitExclude: %v\n", err)
+	cfg := configAction(config, newLinks)
+	plan.add(cfg, excludeAction(cfg.Config, config))
+
+	plan.journal(config, "remove")
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d link(s) would be removed.\n", len(linksToRemove))
+		return nil
 	}
 
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	for _, link := range linksToRemove {
+		fmt.Printf("Removed link: %s\n", link.Path)
+	}
 	return nil
 }
 
-// restoreFromRemote removes the link at local and moves the file from remote back to local
-func restoreFromRemote(link Link, localDir, remoteDir string) error {
+// planRestoreFromRemote plans removing the link at local and moving the file
+// from remote back to local. Ignored paths do not count as local changes of
+// copies.
+func planRestoreFromRemote(link Link, localDir, remoteDir string, state *syncState, ignore *ignoreMatcher) ([]Action, error) {
 	localPath := filepath.Join(localDir, link.Path)
 	remotePath := filepath.Join(remoteDir, link.Path)
 
 	// Check if remote file exists
 	if _, err := os.Stat(remotePath); os.IsNotExist(err) {
-		return fmt.Errorf("remote file does not exist: %s", remotePath)
+		return nil, fmt.Errorf("remote file does not exist: %s", remotePath)
 	}
 
+	var actions []Action
+
 	// Remove the link at local path
+	fi, err := os.Lstat(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
 	switch link.Type {
 	case LinkTypeHard:
 		// Hard link: just remove the local file (it's a hard link to remote)
-		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to remove hard link at %s: %w", localPath, err)
-		}
 	case LinkTypeSymbolic:
-		// Symbolic link: remove the symlink
-		fi, err := os.Lstat(localPath)
-		if err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to stat %s: %w", localPath, err)
+		if err == nil && fi.Mode()&os.ModeSymlink == 0 {
+			return nil, fmt.Errorf("expected symbolic link at %s but found regular file", localPath)
 		}
+	case LinkTypeCopy, LinkTypeReflink:
+		// Copy: the local copy is replaced by the remote file, which must
+		// not lose local changes
 		if err == nil {
-			if fi.Mode()&os.ModeSymlink != 0 {
-				if err := os.Remove(localPath); err != nil {
-					return fmt.Errorf("failed to remove symbolic link at %s: %w", localPath, err)
-				}
-			} else {
-				return fmt.Errorf("expected symbolic link at %s but found regular file", localPath)
+			if err := checkCopyRemovable(link.Path, localPath, remotePath, state, ignore); err != nil {
+				return nil, err
 			}
 		}
 	default:
-		return fmt.Errorf("unknown link type: %s", link.Type)
+		return nil, fmt.Errorf("unknown link type: %s", link.Type)
+	}
+	if err == nil {
+		actions = append(actions, Action{Kind: ActionUnlink, Entry: link.Path, Source: remotePath, Target: localPath, LinkType: link.Type, Relative: isRelativeSymlink(localPath)})
 	}
 
 	// Create parent directory in local if needed
 	localParentDir := filepath.Dir(localPath)
-	if err := os.MkdirAll(localParentDir, 0755); err != nil {
-		return fmt.Errorf("failed to create local directory %s: %w", localParentDir, err)
+	if _, err := os.Stat(localParentDir); os.IsNotExist(err) {
+		actions = append(actions, Action{Kind: ActionMkdir, Entry: link.Path, Target: localParentDir})
 	}
 
-	// Move the file from remote to local
-	if err := os.Rename(remotePath, localPath); err != nil {
-		return fmt.Errorf("failed to move %s to %s: %w", remotePath, localPath, err)
+	// Move the file from remote to local, then clean up empty parent
+	// directories in remote
+	actions = append(actions, Action{Kind: ActionMove, Entry: link.Path, Source: remotePath, Target: localPath})
+	if remoteParentDir := filepath.Dir(remotePath); remoteParentDir != remoteDir {
+		actions = append(actions, Action{Kind: ActionRmdir, Entry: link.Path, Target: remoteParentDir, Root: remoteDir})
 	}
-	fmt.Printf("Restored: %s -> %s\n", remotePath, localPath)
-
-	// Clean up empty parent directories in remote
-	cleanEmptyDirs(filepath.Dir(remotePath), remoteDir)
-
-	return nil
+	if _, ok := state.Copies[link.Path]; ok {
+		actions = append(actions, recordAction(state, link.Path, ""))
+	}
+	return actions, nil
 }
 
 // cleanEmptyDirs removes empty directories from path up to (but not including) stopAt
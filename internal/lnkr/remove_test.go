@@ -125,13 +125,13 @@ func TestRemove(t *testing.T) {
 					if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 						t.Fatalf("failed to create local parent dir: %v", err)
 					}
-					if err := createLink(remotePath, localPath, link.Type); err != nil {
+					if err := createLink(remotePath, localPath, link.Type, false); err != nil {
 						t.Fatalf("failed to create link: %v", err)
 					}
 				}
 			}
 
-			err := Remove(tc.removePath, false)
+			err := Remove(Selector{Paths: []string{tc.removePath}}, false)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error but got none")
@@ -184,11 +184,11 @@ func TestRemoveCleansEmptyRemoteDirs(t *testing.T) {
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 		t.Fatalf("failed to create local parent dir: %v", err)
 	}
-	if err := createLink(filepath.Join(remoteDir, "sub", "dir", "a.txt"), localPath, LinkTypeSymbolic); err != nil {
+	if err := createLink(filepath.Join(remoteDir, "sub", "dir", "a.txt"), localPath, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("failed to create link: %v", err)
 	}
 
-	if err := Remove("sub/dir/a.txt", false); err != nil {
+	if err := Remove(Selector{Paths: []string{"sub/dir/a.txt"}}, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
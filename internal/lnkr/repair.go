@@ -0,0 +1,278 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the timestamp appended to backups of replaced files.
+const backupTimeFormat = "20060102T150405"
+
+// backupMarker separates the original name of a backup from its timestamp.
+const backupMarker = ".lnkr-backup-"
+
+// repairItem is a single problem found by Repair with the actions fixing it.
+type repairItem struct {
+	entry   string
+	problem string
+	actions []Action
+}
+
+// Repair fixes links that status reports as broken: symbolic links that
+// point to the wrong target or nowhere are re-pointed to remote, and hard
+// links that diverged (e.g. after an editor's atomic save replaced the
+// local file) are linked again, keeping the newer content in remote and a
+// backup of the other copy. Every repair is confirmed unless assumeYes is
+// set. With dryRun, the planned actions are printed instead of applied.
+func Repair(assumeYes, dryRun bool) error {
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if len(config.Links) == 0 {
+		fmt.Printf("No links found in %s\n", ConfigFileName)
+		return nil
+	}
+
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	now := time.Now()
+	var items []repairItem
+	var errorCount int
+	for _, link := range config.Links {
+		found, err := planRepairEntry(link, localDir, remoteDir, config, now)
+		if err != nil {
+			fmt.Printf("Error: cannot repair %s: %v\n", link.Path, err)
+			errorCount++
+			continue
+		}
+		items = append(items, found...)
+	}
+
+	if len(items) == 0 {
+		if errorCount > 0 {
+			return fmt.Errorf("%d link(s) could not be repaired", errorCount)
+		}
+		fmt.Println("Nothing to repair.")
+		return nil
+	}
+
+	plan := &Plan{}
+	for _, item := range items {
+		fmt.Printf("%s: %s\n", item.entry, item.problem)
+		if dryRun {
+			for _, a := range item.actions {
+				fmt.Printf("  Would %s\n", a)
+			}
+			continue
+		}
+		if !assumeYes && !confirm(fmt.Sprintf("Repair %s?", item.entry)) {
+			fmt.Printf("Skipped: %s\n", item.entry)
+			continue
+		}
+		plan.add(item.actions...)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d link(s) would be repaired, %d error(s).\n", len(items), errorCount)
+		return nil
+	}
+	if len(plan.Actions) == 0 {
+		fmt.Println("Nothing repaired.")
+		return nil
+	}
+
+	plan.journal(config, "repair")
+	failed, err := plan.ApplyEach(func(entry string, err error) {
+		fmt.Printf("Error: cannot repair %s: %v\n", entry, err)
+	})
+	if err != nil {
+		return err
+	}
+	errorCount += failed
+
+	if errorCount > 0 {
+		return fmt.Errorf("%d link(s) could not be repaired", errorCount)
+	}
+	fmt.Println("Repair completed.")
+	return nil
+}
+
+// planRepairEntry returns the repairs needed by a single entry. Entries
+// that are fine, not linked yet or of a type without repairs yield none.
+func planRepairEntry(link Link, localDir, remoteDir string, config *Config, now time.Time) ([]repairItem, error) {
+	localPath := filepath.Join(localDir, link.Path)
+	remotePath := filepath.Join(remoteDir, link.Path)
+
+	fi, err := os.Lstat(localPath)
+	if os.IsNotExist(err) {
+		// Missing links are created by 'lnkr link'
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+
+	switch link.Type {
+	case LinkTypeSymbolic:
+		item, err := planRepairSymlink(link, localPath, remotePath, fi, config)
+		if err != nil || item == nil {
+			return nil, err
+		}
+		return []repairItem{*item}, nil
+	case LinkTypeHard:
+		if !fi.IsDir() {
+			item, err := planRepairHardLink(link.Path, localPath, remotePath, fi, now)
+			if err != nil || item == nil {
+				return nil, err
+			}
+			return []repairItem{*item}, nil
+		}
+		var items []repairItem
+		err := filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(localPath, p)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+			if p != localPath {
+				if skip, err := config.ignores.walkSkip(filepath.Join(link.Path, rel), info.IsDir()); skip {
+					return err
+				}
+			}
+			if info.IsDir() || isBackupPath(p) {
+				return nil
+			}
+			// Files added locally after linking are not lnkr's to repair
+			remoteFile := filepath.Join(remotePath, rel)
+			if _, err := os.Lstat(remoteFile); os.IsNotExist(err) {
+				fmt.Printf("Skipping (not in remote): %s\n", p)
+				return nil
+			}
+			item, err := planRepairHardLink(link.Path, p, remoteFile, info, now)
+			if err != nil {
+				return err
+			}
+			if item != nil {
+				items = append(items, *item)
+			}
+			return nil
+		})
+		return items, err
+	default:
+		return nil, nil
+	}
+}
+
+// planRepairSymlink re-points a symbolic link whose target is wrong or
+// missing to the remote path. Anything other than a symbolic link is left
+// alone since it may hold data.
+func planRepairSymlink(link Link, localPath, remotePath string, fi os.FileInfo, config *Config) (*repairItem, error) {
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return nil, nil
+	}
+	target, err := os.Readlink(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read link %s: %w", localPath, err)
+	}
+	if _, err := os.Stat(localPath); err == nil && symlinkPointsTo(localPath, target, remotePath) {
+		return nil, nil
+	}
+	if _, err := os.Stat(remotePath); err != nil {
+		return nil, fmt.Errorf("remote path does not exist: %s", remotePath)
+	}
+
+	problem := fmt.Sprintf("wrong target %s", target)
+	if _, err := os.Stat(localPath); os.IsNotExist(err) {
+		problem = fmt.Sprintf("dangling link to %s", target)
+	}
+	return &repairItem{
+		entry:   link.Path,
+		problem: problem,
+		actions: []Action{
+			{Kind: ActionUnlink, Entry: link.Path, Source: target, Target: localPath, LinkType: LinkTypeSymbolic},
+			{Kind: ActionLink, Entry: link.Path, Source: remotePath, Target: localPath, LinkType: LinkTypeSymbolic, Relative: config.relativeSymlink(link)},
+		},
+	}, nil
+}
+
+// planRepairHardLink links a local file that is no longer a hard link to
+// its remote file again. When the contents differ, the newer one is kept
+// in remote and the other is moved aside to a timestamped backup next to
+// it, outside the project.
+func planRepairHardLink(entry, localPath, remotePath string, fi os.FileInfo, now time.Time) (*repairItem, error) {
+	if !fi.Mode().IsRegular() {
+		return nil, nil
+	}
+	remoteInfo, err := os.Stat(remotePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("remote file does not exist: %s", remotePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", remotePath, err)
+	}
+	if compareFileIDs(fi, remoteInfo) == "" {
+		return nil, nil
+	}
+	hint := fmt.Sprintf("run 'lnkr switch %s %s' to use a symbolic link instead", entry, LinkTypeSymbolic)
+	if err := checkSameDevice(localPath, remotePath, LinkTypeHard, hint); err != nil {
+		return nil, err
+	}
+
+	localHash, err := hashPath(localPath, entry, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", localPath, err)
+	}
+	remoteHash, err := hashPath(remotePath, entry, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", remotePath, err)
+	}
+
+	item := &repairItem{entry: entry}
+	switch {
+	case localHash == remoteHash:
+		item.problem = fmt.Sprintf("%s is not hard linked (same content)", localPath)
+		item.actions = []Action{
+			{Kind: ActionUnlink, Entry: entry, Source: remotePath, Target: localPath, LinkType: LinkTypeHard},
+		}
+	case fi.ModTime().After(remoteInfo.ModTime()):
+		backup := backupPath(remotePath, now)
+		item.problem = fmt.Sprintf("%s is not hard linked (local is newer; remote is kept as %s)", localPath, backup)
+		item.actions = []Action{
+			{Kind: ActionMove, Entry: entry, Source: remotePath, Target: backup},
+			{Kind: ActionMove, Entry: entry, Source: localPath, Target: remotePath},
+		}
+	default:
+		// Backups are kept in remote, out of the project's git working tree
+		backup := backupPath(remotePath, now)
+		item.problem = fmt.Sprintf("%s is not hard linked (remote is newer; local is kept as %s)", localPath, backup)
+		item.actions = []Action{
+			{Kind: ActionMove, Entry: entry, Source: localPath, Target: backup},
+		}
+	}
+	item.actions = append(item.actions, Action{Kind: ActionLink, Entry: entry, Source: remotePath, Target: localPath, LinkType: LinkTypeHard})
+	return item, nil
+}
+
+// backupPath returns the path a file replaced at now is backed up to.
+func backupPath(path string, now time.Time) string {
+	return path + backupMarker + now.Format(backupTimeFormat)
+}
+
+// isBackupPath reports whether path is a backup made by lnkr.
+func isBackupPath(path string) bool {
+	return strings.Contains(filepath.Base(path), backupMarker)
+}
@@ -0,0 +1,174 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepair(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name  string
+		link  Link
+		setup func(t *testing.T, localDir, remoteDir string)
+		// wantRemote is the remote content expected after the repair
+		wantRemote string
+		// wantBackup is the content of the backup expected next to the
+		// remote file, empty when none is expected
+		wantBackup string
+	}{
+		{
+			name: "SymbolicWrongTarget",
+			link: Link{Path: "a.txt", Type: LinkTypeSymbolic},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "other.txt": "o"})
+				if err := os.Symlink(filepath.Join(remoteDir, "other.txt"), filepath.Join(localDir, "a.txt")); err != nil {
+					t.Fatalf("failed to create symlink: %v", err)
+				}
+			},
+			wantRemote: "a",
+		},
+		{
+			name: "SymbolicDangling",
+			link: Link{Path: "a.txt", Type: LinkTypeSymbolic},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+				if err := os.Symlink(filepath.Join(localDir, "old", "a.txt"), filepath.Join(localDir, "a.txt")); err != nil {
+					t.Fatalf("failed to create symlink: %v", err)
+				}
+			},
+			wantRemote: "a",
+		},
+		{
+			name: "HardSameContent",
+			link: Link{Path: "a.txt", Type: LinkTypeHard},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+				writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+			},
+			wantRemote: "a",
+		},
+		{
+			name: "HardLocalNewer",
+			link: Link{Path: "a.txt", Type: LinkTypeHard},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+				writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+				if err := os.Chtimes(filepath.Join(remoteDir, "a.txt"), old, old); err != nil {
+					t.Fatalf("failed to set mtime: %v", err)
+				}
+			},
+			wantRemote: "local",
+			wantBackup: "remote",
+		},
+		{
+			name: "HardRemoteNewer",
+			link: Link{Path: "a.txt", Type: LinkTypeHard},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "remote"})
+				writeFiles(t, localDir, map[string]string{"a.txt": "local"})
+				if err := os.Chtimes(filepath.Join(localDir, "a.txt"), old, old); err != nil {
+					t.Fatalf("failed to set mtime: %v", err)
+				}
+			},
+			wantRemote: "remote",
+			wantBackup: "local",
+		},
+		{
+			name: "HardDirectoryFile",
+			link: Link{Path: "conf", Type: LinkTypeHard},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "remote"})
+				writeFiles(t, localDir, map[string]string{"conf/a.txt": "local"})
+				if err := os.Chtimes(filepath.Join(localDir, "conf", "a.txt"), old, old); err != nil {
+					t.Fatalf("failed to set mtime: %v", err)
+				}
+			},
+			wantRemote: "remote",
+			wantBackup: "local",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: []Link{tc.link}})
+			tc.setup(t, localDir, remoteDir)
+
+			if err := Repair(true, false); err != nil {
+				t.Fatalf("Repair failed: %v", err)
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("failed to load config: %v", err)
+			}
+			if status := checkLinkStatus(tc.link, config); !status.IsLink || status.Error != "" {
+				t.Fatalf("expected entry to be linked after repair, got %+v", status)
+			}
+
+			file := tc.link.Path
+			if tc.link.Path == "conf" {
+				file = filepath.Join("conf", "a.txt")
+			}
+			if got := readFile(t, filepath.Join(remoteDir, file)); got != tc.wantRemote {
+				t.Fatalf("unexpected remote content: got %q, want %q", got, tc.wantRemote)
+			}
+
+			// Backups never land in the project's working tree
+			if local, _ := filepath.Glob(filepath.Join(localDir, file+".lnkr-backup-*")); len(local) != 0 {
+				t.Fatalf("unexpected local backups: %v", local)
+			}
+			backups, _ := filepath.Glob(filepath.Join(remoteDir, file+".lnkr-backup-*"))
+			if tc.wantBackup == "" {
+				if len(backups) != 0 {
+					t.Fatalf("unexpected backups: %v", backups)
+				}
+				return
+			}
+			if len(backups) != 1 {
+				t.Fatalf("expected one backup, got %v", backups)
+			}
+			if got := readFile(t, backups[0]); got != tc.wantBackup {
+				t.Fatalf("unexpected backup content: got %q, want %q", got, tc.wantBackup)
+			}
+		})
+	}
+}
+
+func TestRepairDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}}})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "other.txt": "o"})
+	wrong := filepath.Join(remoteDir, "other.txt")
+	if err := os.Symlink(wrong, filepath.Join(localDir, "a.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	if err := Repair(true, true); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if target, _ := os.Readlink(filepath.Join(localDir, "a.txt")); target != wrong {
+		t.Fatalf("dry run changed the link: got %q, want %q", target, wrong)
+	}
+}
+
+func TestRepairHardDirectorySkipsLocalOnlyFiles(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{{Path: "conf", Type: LinkTypeHard}}})
+	writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a"})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a", "conf/new.txt": "local only"})
+
+	if err := Repair(true, false); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+
+	// The diverged file is linked again, the local-only one is left alone
+	assertLink(t, filepath.Join(localDir, "conf", "a.txt"), filepath.Join(remoteDir, "conf", "a.txt"), LinkTypeHard)
+	if got := readFile(t, filepath.Join(localDir, "conf", "new.txt")); got != "local only" {
+		t.Fatalf("local-only file was changed: got %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(remoteDir, "conf", "new.txt")); !os.IsNotExist(err) {
+		t.Fatalf("local-only file was added to remote")
+	}
+}
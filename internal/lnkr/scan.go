@@ -0,0 +1,108 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// UnmanagedState is the state of a path that no entry covers, found by
+// 'lnkr status --all'.
+type UnmanagedState string
+
+// Unmanaged path states
+const (
+	UnmanagedRemoteOnly     UnmanagedState = "remote-only"     // in remote, not in local
+	UnmanagedLocalIdentical UnmanagedState = "local-identical" // in both, with the same content
+	UnmanagedLocalDifferent UnmanagedState = "local-different" // in both, with different content
+	UnmanagedLocalOnly      UnmanagedState = "local-only"      // in local next to entries, not in remote
+)
+
+// UnmanagedStatus is a path that no entry covers.
+type UnmanagedStatus struct {
+	Path       string         `json:"path" yaml:"path"`
+	LocalPath  string         `json:"local_path" yaml:"local_path"`
+	RemotePath string         `json:"remote_path" yaml:"remote_path"`
+	State      UnmanagedState `json:"state" yaml:"state"`
+}
+
+// scanUnmanaged walks remote and the local directories holding entries for
+// paths no entry covers: remote paths missing locally or shadowed by a
+// local copy, and local files next to entries that are not in remote.
+// Ignored paths are skipped on both sides. Paths are reported as coarse as
+// possible and sorted.
+func scanUnmanaged(localDir, remoteDir string, links []Link, ignore *ignoreMatcher) ([]UnmanagedStatus, error) {
+	var result []UnmanagedStatus
+	add := func(rel string, state UnmanagedState) {
+		result = append(result, UnmanagedStatus{
+			Path:       rel,
+			LocalPath:  filepath.Join(localDir, rel),
+			RemotePath: filepath.Join(remoteDir, rel),
+			State:      state,
+		})
+	}
+
+	remoteOnly, err := findUnmanaged(remoteDir, links, ignore)
+	if err != nil {
+		return nil, err
+	}
+	inRemote := make(map[string]bool)
+	for _, rel := range remoteOnly {
+		inRemote[rel] = true
+		localPath := filepath.Join(localDir, rel)
+		if _, err := os.Lstat(localPath); os.IsNotExist(err) {
+			add(rel, UnmanagedRemoteOnly)
+			continue
+		}
+		localHash, err := hashPath(localPath, rel, ignore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", localPath, err)
+		}
+		remoteHash, err := hashPath(filepath.Join(remoteDir, rel), rel, ignore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", filepath.Join(remoteDir, rel), err)
+		}
+		if localHash == remoteHash {
+			add(rel, UnmanagedLocalIdentical)
+		} else {
+			add(rel, UnmanagedLocalDifferent)
+		}
+	}
+
+	// Only directories holding entries are searched locally; the rest of
+	// the project is not lnkr's business
+	localOnly, err := findUnmanaged(localDir, links, ignore)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range localOnly {
+		if filepath.Dir(rel) == "." || inRemote[rel] || isBackupPath(rel) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(remoteDir, rel)); os.IsNotExist(err) {
+			add(rel, UnmanagedLocalOnly)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+// scanConfigUnmanaged runs scanUnmanaged on the directories of config.
+func scanConfigUnmanaged(config *Config) ([]UnmanagedStatus, error) {
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand remote path: %w", err)
+	}
+	if localDir == "" || remoteDir == "" {
+		return nil, fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")
+	}
+	return scanUnmanaged(localDir, remoteDir, config.Links, config.ignores)
+}
@@ -0,0 +1,61 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanUnmanaged(t *testing.T) {
+	localDir, remoteDir := setupProject(t, nil)
+	links := []Link{
+		{Path: "managed.txt", Type: LinkTypeSymbolic},
+		{Path: "conf/a.txt", Type: LinkTypeHard},
+	}
+	writeFiles(t, remoteDir, map[string]string{
+		"managed.txt":    "m",
+		"conf/a.txt":     "a",
+		"conf/b.txt":     "b",
+		"same.txt":       "same",
+		"changed.txt":    "remote",
+		"old/c.txt":      "c",
+		ConfigFileName:   "",
+		"x.txt.lnkr-tmp": "tmp",
+	})
+	writeFiles(t, localDir, map[string]string{
+		"same.txt":               "same",
+		"changed.txt":            "local",
+		"conf/new.txt":           "n",
+		"conf/a.lnkr-backup-123": "backup",
+		"src/main.go":            "package main",
+	})
+	if err := os.Symlink(filepath.Join(remoteDir, "managed.txt"), filepath.Join(localDir, "managed.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if err := os.Link(filepath.Join(remoteDir, "conf", "a.txt"), filepath.Join(localDir, "conf", "a.txt")); err != nil {
+		t.Fatalf("failed to create hard link: %v", err)
+	}
+
+	got, err := scanUnmanaged(localDir, remoteDir, links, nil)
+	if err != nil {
+		t.Fatalf("scanUnmanaged failed: %v", err)
+	}
+	want := []struct {
+		path  string
+		state UnmanagedState
+	}{
+		{"changed.txt", UnmanagedLocalDifferent},
+		{"conf/b.txt", UnmanagedRemoteOnly},
+		{"conf/new.txt", UnmanagedLocalOnly},
+		{"old", UnmanagedRemoteOnly},
+		{"same.txt", UnmanagedLocalIdentical},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected unmanaged paths: got %+v, want %+v", got, want)
+	}
+	for i, w := range want {
+		if got[i].Path != w.path || got[i].State != w.state {
+			t.Fatalf("unexpected unmanaged path %d: got %s (%s), want %s (%s)", i, got[i].Path, got[i].State, w.path, w.state)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// StateBroken selects the entries that are neither linked nor missing, the
+// ones 'lnkr status --check' reports as broken.
+const StateBroken = "broken"
+
+// Selector picks entries of the configuration for unlink, switch, remove and
+// status. An entry is selected when it matches one of Paths (or Paths is
+// empty), one of Types (or Types is empty) and one of States (or States is
+// empty).
+type Selector struct {
+	// Paths are paths or glob patterns, absolute or relative to the current
+	// directory. A path selects the entry itself and the entries inside
+	// it, a pattern the entries it matches and the entries inside them.
+	Paths []string
+	// Types are link types ("symbolic" is accepted as an alias for "sym").
+	Types []string
+	// States are link states (see LinkState) or StateBroken.
+	States []string
+}
+
+// IsEmpty reports whether the selector selects every entry.
+func (s Selector) IsEmpty() bool {
+	return len(s.Paths) == 0 && len(s.Types) == 0 && len(s.States) == 0
+}
+
+// pathSelector is a path of a Selector resolved against the local
+// directory: rel for a path, re for a glob pattern.
+type pathSelector struct {
+	rel string
+	re  *regexp.Regexp
+}
+
+// matches reports whether entry is selected by p.
+func (p pathSelector) matches(entry string) bool {
+	if p.re == nil {
+		return entry == p.rel || strings.HasPrefix(entry, p.rel+string(os.PathSeparator))
+	}
+	for dir := entry; dir != "." && dir != string(os.PathSeparator); dir = filepath.Dir(dir) {
+		if p.re.MatchString(filepath.ToSlash(dir)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve checks the types and states of s and resolves its paths against
+// localDir.
+func (s Selector) resolve(localDir string) ([]pathSelector, error) {
+	for _, t := range s.Types {
+		if !ValidLinkType(t) && t != "symbolic" {
+			return nil, fmt.Errorf("invalid link type: %s. Must be '%s', '%s', '%s' or '%s'", t, LinkTypeHard, LinkTypeSymbolic, LinkTypeCopy, LinkTypeReflink)
+		}
+	}
+	for _, state := range s.States {
+		if !validSelectorState(state) {
+			return nil, fmt.Errorf("invalid state: %s. Must be '%s' or a link state such as '%s' or '%s'", state, StateBroken, StateMissing, StateLinked)
+		}
+	}
+
+	var paths []pathSelector
+	for _, input := range s.Paths {
+		if _, err := os.Lstat(input); err != nil && isGlobPattern(input) {
+			re, err := localGlobRegexp(input, localDir)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, pathSelector{re: re})
+			continue
+		}
+		// Normalize the input path (trailing slash, "./" prefix,
+		// CWD-relative)
+		rel, err := resolveLocalRelPath(input, localDir)
+		if err != nil {
+			rel = filepath.Clean(input)
+		}
+		paths = append(paths, pathSelector{rel: rel})
+	}
+	return paths, nil
+}
+
+// localGlobRegexp compiles pattern, absolute or relative to the current
+// directory, to a regular expression matching slash-separated paths
+// relative to localDir.
+func localGlobRegexp(pattern, localDir string) (*regexp.Regexp, error) {
+	abs := pattern
+	if !filepath.IsAbs(abs) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		abs = filepath.Join(cwd, pattern)
+	}
+	root := abs
+	for isGlobPattern(root) {
+		root = filepath.Dir(root)
+	}
+	rel, ok := relPathWithin(localDir, filepath.Join(root, "x"))
+	if !ok {
+		return nil, fmt.Errorf("path is outside the local directory (%s): %s", localDir, pattern)
+	}
+	relPattern := filepath.ToSlash(filepath.Join(filepath.Dir(rel), abs[len(root):]))
+	re, err := globRegexp(strings.TrimPrefix(relPattern, "./"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// validSelectorState reports whether state can be selected.
+func validSelectorState(state string) bool {
+	switch LinkState(state) {
+	case StateLinked, StateMissing, StateTargetMissing, StateWrongTarget, StateNotLinked,
+		StateLocalChanged, StateRemoteChanged, StateBothChanged, StateError, StateBroken:
+		return true
+	default:
+		return false
+	}
+}
+
+// matchesState reports whether an entry in state is selected by states.
+func matchesState(state LinkState, states []string) bool {
+	for _, s := range states {
+		if LinkState(s) == state || (s == StateBroken && state != StateLinked && state != StateMissing) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectEntries returns the entries of config selected by sel, in the order
+// of the configuration.
+func selectEntries(config *Config, sel Selector) ([]Link, error) {
+	if sel.IsEmpty() {
+		return slices.Clone(config.Links), nil
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand local path: %w", err)
+	}
+	paths, err := sel.resolve(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Link
+	for _, link := range config.Links {
+		if len(paths) > 0 && !slices.ContainsFunc(paths, func(p pathSelector) bool { return p.matches(link.Path) }) {
+			continue
+		}
+		if len(sel.Types) > 0 && !slices.ContainsFunc(sel.Types, func(t string) bool { return normalizeLinkType(t) == normalizeLinkType(link.Type) }) {
+			continue
+		}
+		if len(sel.States) > 0 && !matchesState(checkLinkStatus(link, config).State, sel.States) {
+			continue
+		}
+		selected = append(selected, link)
+	}
+	return selected, nil
+}
+
+// normalizeLinkType returns the link type with "symbolic" and "" read as
+// "sym".
+func normalizeLinkType(linkType string) string {
+	if linkType == "" || linkType == "symbolic" {
+		return LinkTypeSymbolic
+	}
+	return linkType
+}
@@ -0,0 +1,90 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSelectEntries(t *testing.T) {
+	links := []Link{
+		{Path: ".vscode/settings.json", Type: LinkTypeSymbolic},
+		{Path: "data/a.txt", Type: LinkTypeHard},
+		{Path: "data/sub/b.txt", Type: LinkTypeHard},
+		{Path: "editor.conf", Type: LinkTypeCopy},
+		{Path: "missing.txt", Type: LinkTypeSymbolic},
+		{Path: "wrong.txt", Type: LinkTypeSymbolic},
+	}
+
+	testCases := []struct {
+		name    string
+		sel     Selector
+		want    []string
+		wantErr bool
+	}{
+		{name: "Empty", sel: Selector{}, want: []string{".vscode/settings.json", "data/a.txt", "data/sub/b.txt", "editor.conf", "missing.txt", "wrong.txt"}},
+		{name: "Path", sel: Selector{Paths: []string{"editor.conf"}}, want: []string{"editor.conf"}},
+		{name: "Directory", sel: Selector{Paths: []string{"data/"}}, want: []string{"data/a.txt", "data/sub/b.txt"}},
+		{name: "Glob", sel: Selector{Paths: []string{"*.txt"}}, want: []string{"missing.txt", "wrong.txt"}},
+		{name: "GlobMatchesDirectory", sel: Selector{Paths: []string{"data/*"}}, want: []string{"data/a.txt", "data/sub/b.txt"}},
+		{name: "DoubleStarGlob", sel: Selector{Paths: []string{"**/b.txt"}}, want: []string{"data/sub/b.txt"}},
+		{name: "Type", sel: Selector{Types: []string{LinkTypeHard}}, want: []string{"data/a.txt", "data/sub/b.txt"}},
+		{name: "TypeAlias", sel: Selector{Types: []string{"symbolic", LinkTypeCopy}}, want: []string{".vscode/settings.json", "editor.conf", "missing.txt", "wrong.txt"}},
+		{name: "StateMissing", sel: Selector{States: []string{string(StateMissing)}}, want: []string{"missing.txt"}},
+		{name: "StateBroken", sel: Selector{States: []string{StateBroken}}, want: []string{"wrong.txt"}},
+		{name: "PathAndType", sel: Selector{Paths: []string{"data"}, Types: []string{LinkTypeSymbolic}}, want: nil},
+		{name: "InvalidType", sel: Selector{Types: []string{"soft"}}, wantErr: true},
+		{name: "InvalidState", sel: Selector{States: []string{"gone"}}, wantErr: true},
+		{name: "GlobOutsideLocal", sel: Selector{Paths: []string{"../*"}}, wantErr: true},
+	}
+
+	localDir, remoteDir := setupProject(t, &Config{Links: links})
+	writeFiles(t, remoteDir, map[string]string{
+		".vscode/settings.json": "{}",
+		"data/a.txt":            "a",
+		"data/sub/b.txt":        "b",
+		"editor.conf":           "e",
+		"missing.txt":           "m",
+		"wrong.txt":             "w",
+		"elsewhere.txt":         "x",
+	})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+	for _, p := range []string{"missing.txt", "wrong.txt"} {
+		if err := os.Remove(filepath.Join(localDir, p)); err != nil {
+			t.Fatalf("failed to remove link: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(remoteDir, "elsewhere.txt"), filepath.Join(localDir, "wrong.txt")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	t.Chdir(localDir)
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := selectEntries(config, tc.sel)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectEntries failed: %v", err)
+			}
+			var got []string
+			for _, link := range selected {
+				got = append(got, filepath.ToSlash(link.Path))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("unexpected selection: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -4,25 +4,72 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-	"syscall"
+)
+
+// LinkState is the state of an entry reported by 'lnkr status'. The values
+// are stable, for scripts consuming the machine-readable formats.
+type LinkState string
+
+// Link states
+const (
+	StateLinked        LinkState = "linked"         // linked to remote (copies: in sync)
+	StateMissing       LinkState = "missing"        // local path does not exist
+	StateTargetMissing LinkState = "target-missing" // remote path does not exist
+	StateWrongTarget   LinkState = "wrong-target"   // symbolic link points elsewhere
+	StateNotLinked     LinkState = "not-linked"     // local path exists but is not linked to remote
+	StateLocalChanged  LinkState = "local-changed"  // copy changed locally since the last sync
+	StateRemoteChanged LinkState = "remote-changed" // copy changed in remote since the last sync
+	StateBothChanged   LinkState = "both-changed"   // copy changed on both sides
+	StateError         LinkState = "error"          // the state could not be determined
 )
 
 type LinkStatus struct {
-	Path       string
-	LocalPath  string
-	RemotePath string
-	Type       string
-	Exists     bool
-	IsLink     bool
-	Error      string
+	Path       string    `json:"path" yaml:"path"`
+	LocalPath  string    `json:"local_path" yaml:"local_path"`
+	RemotePath string    `json:"remote_path" yaml:"remote_path"`
+	Type       string    `json:"type" yaml:"type"`
+	State      LinkState `json:"state" yaml:"state"`
+	Exists     bool      `json:"exists" yaml:"exists"`
+	IsLink     bool      `json:"is_link" yaml:"is_link"`
+	// Sync is the sync state of a copy entry (e.g. "IN SYNC"), empty for
+	// other link types.
+	Sync string `json:"sync,omitempty" yaml:"sync,omitempty"`
+	// Target is what a symbolic link points to, as written in the link.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+	// Error describes the problem in words, empty for healthy links.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+	// Files is the state of every file of a hard-linked directory.
+	Files []FileStatus `json:"files,omitempty" yaml:"files,omitempty"`
 }
 
-func Status() error {
+// Status prints the state of the entries selected by sel: as a table when
+// format is empty or "table", or in one of the machine-readable formats
+// (see writeStatusReport). With verbose, the table lists every file of
+// hard-linked directories below their row. With all, paths no entry covers
+// are reported too (see scanUnmanaged).
+func Status(format string, verbose, all bool, sel Selector) error {
+	if !validStatusFormat(format) {
+		return fmt.Errorf("invalid format: %s. Must be 'table', 'json', 'yaml', 'porcelain' or a Go template", format)
+	}
+
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	links, err := selectEntries(config, sel)
+	if err != nil {
+		return err
+	}
+
+	if format != "" && format != StatusFormatTable {
+		report, err := newStatusReport(config, links, all)
+		if err != nil {
+			return err
+		}
+		return writeStatusReport(os.Stdout, format, report)
+	}
 
 	// Display root paths
 	localRoot := config.Local
@@ -33,24 +80,42 @@ func Status() error {
 	if remoteRoot == "" {
 		remoteRoot = "(not set)"
 	}
+	if _, err := os.Lstat(config.journalPath()); err == nil {
+		fmt.Printf("Warning: an interrupted operation was found, run 'lnkr recover'\n\n")
+	}
+
 	fmt.Printf("Local Root:  %s\n", localRoot)
 	fmt.Printf("Remote Root: %s\n", remoteRoot)
 	fmt.Println()
 
-	if len(config.Links) == 0 {
-		fmt.Printf("No links found in %s\n", ConfigFileName)
-		return nil
-	}
-
 	// Get expanded paths for display
 	localExpanded, _ := config.GetLocalExpanded()
 	remoteExpanded, _ := config.GetRemoteExpanded()
 
 	var statuses []LinkStatus
-	for _, link := range config.Links {
+	for _, link := range links {
 		status := checkLinkStatus(link, config)
 		statuses = append(statuses, status)
 	}
+	printStatusTable(statuses, localExpanded, remoteExpanded, verbose)
+
+	if all {
+		unmanaged, err := scanConfigUnmanaged(config)
+		if err != nil {
+			return err
+		}
+		printUnmanaged(unmanaged, localExpanded, remoteExpanded)
+	}
+	return nil
+}
+
+// printStatusTable prints the statuses as a table with placeholder paths.
+// With verbose, the files of hard-linked directories are listed too.
+func printStatusTable(statuses []LinkStatus, localExpanded, remoteExpanded string, verbose bool) {
+	if len(statuses) == 0 {
+		fmt.Printf("No links found in %s\n", ConfigFileName)
+		return
+	}
 
 	// Calculate max width for each column using placeholder paths
 	maxLocalPath := len("Local Path")
@@ -87,8 +152,98 @@ func Status() error {
 		remoteDisplay := toPlaceholderPath(s.RemotePath, remoteExpanded, "{remote}")
 		st := getStatusText(s)
 		fmt.Printf("%-*s  %-*s  %-*s  %-*s\n", maxLocalPath, localDisplay, maxRemotePath, remoteDisplay, maxType, s.Type, maxStatus, st)
+		if verbose {
+			printFileStatuses(s)
+		}
+	}
+}
+
+// printUnmanaged prints the paths no entry covers below the status table.
+func printUnmanaged(unmanaged []UnmanagedStatus, localExpanded, remoteExpanded string) {
+	fmt.Println()
+	if len(unmanaged) == 0 {
+		fmt.Println("No unmanaged paths found.")
+		return
+	}
+	fmt.Println("Unmanaged paths:")
+	for _, u := range unmanaged {
+		path := toPlaceholderPath(u.LocalPath, localExpanded, "{local}")
+		if u.State == UnmanagedRemoteOnly {
+			path = toPlaceholderPath(u.RemotePath, remoteExpanded, "{remote}")
+		}
+		fmt.Printf("  %-15s  %s\n", u.State, path)
+	}
+}
+
+// printFileStatuses lists the files of a hard-linked directory below its
+// row in the status table.
+func printFileStatuses(status LinkStatus) {
+	for _, f := range status.Files {
+		line := fmt.Sprintf("  %-14s  %s", f.State, filepath.Join(status.Path, f.Path))
+		if f.Error != "" {
+			line += fmt.Sprintf(" (%s)", f.Error)
+		}
+		fmt.Println(line)
+	}
+}
+
+// Exit codes of 'lnkr status --check'
+const (
+	CheckExitConfig  = 2 // the configuration is missing or invalid
+	CheckExitMissing = 3 // some links are missing, all others are healthy
+	CheckExitBroken  = 4 // some links are broken or diverged, or an operation was interrupted
+)
+
+// CheckStatus checks the entries selected by sel and prints nothing when
+// all of them are healthy. Otherwise the unhealthy entries are printed like with
+// --format porcelain and an *ExitError tells missing links apart from
+// broken or diverged ones and from an invalid configuration.
+func CheckStatus(sel Selector) error {
+	config, err := loadConfig()
+	if err != nil {
+		return &ExitError{Code: CheckExitConfig, Err: fmt.Errorf("failed to load configuration: %w", err)}
+	}
+	if config.Local == "" || config.Remote == "" {
+		return &ExitError{Code: CheckExitConfig, Err: fmt.Errorf("local or remote directory not configured. Run 'lnkr init' first")}
+	}
+	if _, err := config.GetLocalExpanded(); err != nil {
+		return &ExitError{Code: CheckExitConfig, Err: fmt.Errorf("failed to expand local path: %w", err)}
+	}
+	if _, err := config.GetRemoteExpanded(); err != nil {
+		return &ExitError{Code: CheckExitConfig, Err: fmt.Errorf("failed to expand remote path: %w", err)}
 	}
 
+	links, err := selectEntries(config, sel)
+	if err != nil {
+		return &ExitError{Code: CheckExitConfig, Err: err}
+	}
+	report, err := newStatusReport(config, links, false)
+	if err != nil {
+		return err
+	}
+	var missing, broken []LinkStatus
+	for _, s := range report.Links {
+		switch s.State {
+		case StateLinked:
+		case StateMissing:
+			missing = append(missing, s)
+		default:
+			broken = append(broken, s)
+		}
+	}
+	unhealthy := StatusReport{Links: append(missing, broken...)}
+	if err := writeStatusReport(os.Stdout, StatusFormatPorcelain, unhealthy); err != nil {
+		return err
+	}
+
+	switch {
+	case report.Interrupted:
+		return &ExitError{Code: CheckExitBroken, Err: fmt.Errorf("an interrupted operation was found, run 'lnkr recover'")}
+	case len(broken) > 0:
+		return &ExitError{Code: CheckExitBroken, Err: fmt.Errorf("%d link(s) broken or diverged, %d missing", len(broken), len(missing))}
+	case len(missing) > 0:
+		return &ExitError{Code: CheckExitMissing, Err: fmt.Errorf("%d link(s) missing", len(missing))}
+	}
 	return nil
 }
 
@@ -111,6 +266,12 @@ func getStatusText(status LinkStatus) string {
 		return status.Error
 	}
 	if status.IsLink {
+		if status.Sync != "" {
+			return status.Sync
+		}
+		if len(status.Files) > 0 {
+			return fmt.Sprintf("LINKED (%d files)", len(status.Files))
+		}
 		return "LINKED"
 	}
 	return "NOT LINKED"
@@ -124,10 +285,12 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 
 	// Validate config first
 	if config.Local == "" {
+		status.State = StateError
 		status.Error = "Local directory not configured"
 		return status
 	}
 	if config.Remote == "" {
+		status.State = StateError
 		status.Error = "Remote directory not configured"
 		return status
 	}
@@ -135,11 +298,13 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 	// Expand paths with environment variables
 	localDir, err := config.GetLocalExpanded()
 	if err != nil {
+		status.State = StateError
 		status.Error = fmt.Sprintf("Failed to expand local path: %v", err)
 		return status
 	}
 	remoteDir, err := config.GetRemoteExpanded()
 	if err != nil {
+		status.State = StateError
 		status.Error = fmt.Sprintf("Failed to expand remote path: %v", err)
 		return status
 	}
@@ -152,6 +317,7 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 	info, err := os.Lstat(status.LocalPath)
 	if os.IsNotExist(err) {
 		status.Exists = false
+		status.State = StateMissing
 		status.Error = "LINK NOT FOUND"
 		return status
 	}
@@ -162,6 +328,7 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 	case LinkTypeSymbolic:
 		// Check if it's actually a symbolic link
 		if info.Mode()&os.ModeSymlink == 0 {
+			status.State = StateNotLinked
 			status.Error = "Not a symbolic link"
 			return status
 		}
@@ -169,33 +336,52 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 		// Get the target of the symbolic link
 		target, err := os.Readlink(status.LocalPath)
 		if err != nil {
+			status.State = StateError
 			status.Error = fmt.Sprintf("Cannot read link target: %v", err)
 			return status
 		}
+		status.Target = target
 
-		// Check if the target exists
-		if _, err := os.Stat(target); os.IsNotExist(err) {
+		// Check if the target exists (relative targets resolve against the
+		// link's directory)
+		if _, err := os.Stat(status.LocalPath); os.IsNotExist(err) {
+			status.State = StateTargetMissing
 			status.Error = "TARGET NOT FOUND"
 			return status
 		}
 
 		// Check if the target path is correct (should point to remote location)
-		if target != status.RemotePath {
+		if !symlinkPointsTo(status.LocalPath, target, status.RemotePath) {
+			status.State = StateWrongTarget
 			status.Error = fmt.Sprintf("Wrong target: %s (expected: %s)", target, status.RemotePath)
 			return status
 		}
 
+		status.State = StateLinked
 		status.IsLink = true
 
 	case LinkTypeHard:
 		// Check if it's a directory
 		if info.IsDir() {
-			// For directories, check recursively that all files are hard linked
-			err := checkHardLinksRecursively(status.LocalPath, status.RemotePath)
+			// For directories, check the state of every file on both sides
+			if _, err := os.Stat(status.RemotePath); os.IsNotExist(err) {
+				status.State = StateTargetMissing
+				status.Error = "TARGET NOT FOUND"
+				return status
+			}
+			files, err := checkHardLinkedDir(link.Path, status.LocalPath, status.RemotePath, config.ignores)
 			if err != nil {
+				status.State = StateError
 				status.Error = err.Error()
 				return status
 			}
+			status.Files = files
+			if summary := summarizeFiles(files); summary != "" {
+				status.State = StateNotLinked
+				status.Error = summary
+				return status
+			}
+			status.State = StateLinked
 			status.IsLink = true
 			return status
 		}
@@ -203,95 +389,219 @@ func checkLinkStatus(link Link, config *Config) LinkStatus {
 		// Check if the target file exists
 		targetInfo, err := os.Stat(status.RemotePath)
 		if os.IsNotExist(err) {
+			status.State = StateTargetMissing
 			status.Error = "TARGET NOT FOUND"
 			return status
 		}
 		if err != nil {
+			status.State = StateError
 			status.Error = fmt.Sprintf("Cannot access target file: %v", err)
 			return status
 		}
 
-		// Check if both files have the same inode (hard link check)
-		if info.Sys() == nil || targetInfo.Sys() == nil {
-			status.Error = "Cannot get file system info for inode comparison"
+		// Compare device and inode to verify hard link
+		if msg := compareFileIDs(info, targetInfo); msg != "" {
+			status.State = StateNotLinked
+			status.Error = fmt.Sprintf("Not a hard link (%s)", msg)
 			return status
 		}
 
-		// Compare inodes to verify hard link
-		linkInode := getInode(info)
-		targetInode := getInode(targetInfo)
+		status.State = StateLinked
+		status.IsLink = true
 
-		if linkInode == 0 || targetInode == 0 {
-			status.Error = "Cannot determine inode numbers"
+	case LinkTypeCopy, LinkTypeReflink:
+		if info.Mode()&os.ModeSymlink != 0 {
+			status.State = StateNotLinked
+			status.Error = "Not a copy (symbolic link)"
 			return status
 		}
-
-		if linkInode != targetInode {
-			status.Error = "Not a hard link (different inodes)"
+		if _, err := os.Lstat(status.RemotePath); os.IsNotExist(err) {
+			status.State = StateTargetMissing
+			status.Error = "TARGET NOT FOUND"
 			return status
 		}
 
+		state, err := loadSyncState(config.statePath())
+		if err != nil {
+			status.State = StateError
+			status.Error = err.Error()
+			return status
+		}
+		sync, _, _, err := copyStatus(link.Path, status.LocalPath, status.RemotePath, state, config.ignores)
+		if err != nil {
+			status.State = StateError
+			status.Error = err.Error()
+			return status
+		}
 		status.IsLink = true
+		status.Sync = sync
+		status.State = syncLinkState(sync)
+
+	default:
+		status.State = StateError
+		status.Error = fmt.Sprintf("Unknown link type: %s", link.Type)
 	}
 
 	return status
 }
 
-// checkHardLinksRecursively verifies that all files in localDir are hard linked to corresponding files in remoteDir
-func checkHardLinksRecursively(localDir, remoteDir string) error {
-	return filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
+// syncLinkState returns the state of a copy entry with the given sync
+// status.
+func syncLinkState(sync string) LinkState {
+	switch sync {
+	case SyncLocalChanged:
+		return StateLocalChanged
+	case SyncRemoteChanged:
+		return StateRemoteChanged
+	case SyncBothChanged:
+		return StateBothChanged
+	default:
+		return StateLinked
+	}
+}
+
+// FileState is the state of a file inside a hard-linked directory entry.
+type FileState string
+
+// File states
+const (
+	FileLinked        FileState = "linked"         // hard linked to the remote file
+	FileDiverged      FileState = "diverged"       // local and remote are different files
+	FileMissingRemote FileState = "missing-remote" // local file without a remote counterpart
+	FileMissingLocal  FileState = "missing-local"  // remote file without a local counterpart
+	FileUntracked     FileState = "untracked"      // backup or temporary file left by lnkr, ignored
+)
+
+// FileStatus is the state of a file inside a hard-linked directory entry.
+type FileStatus struct {
+	// Path is relative to the directory of the entry.
+	Path  string    `json:"path" yaml:"path"`
+	State FileState `json:"state" yaml:"state"`
+	// Error describes why a diverged file is not linked.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// checkHardLinkedDir returns the state of every file in localDir and
+// remoteDir, the two sides of entry, sorted by path. Files lnkr leaves
+// behind locally (backups, temporary copies) are reported as untracked and
+// ignored paths are left out.
+func checkHardLinkedDir(entry, localDir, remoteDir string, ignore *ignoreMatcher) ([]FileStatus, error) {
+	var files []FileStatus
+	seen := make(map[string]bool)
+	err := filepath.Walk(localDir, func(localPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Calculate relative path
 		relPath, err := filepath.Rel(localDir, localPath)
 		if err != nil {
 			return fmt.Errorf("failed to get relative path: %w", err)
 		}
+		if localPath != localDir {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, relPath), info.IsDir()); skip {
+				return err
+			}
+		}
+		if info.IsDir() {
+			return nil
+		}
+		seen[relPath] = true
 
+		if isBackupPath(localPath) || isLnkrInternal(info.Name()) {
+			files = append(files, FileStatus{Path: relPath, State: FileUntracked})
+			return nil
+		}
 		remotePath := filepath.Join(remoteDir, relPath)
-
-		// Check if remote file exists
 		remoteInfo, err := os.Stat(remotePath)
 		if os.IsNotExist(err) {
-			return fmt.Errorf("remote file not found: %s", remotePath)
+			files = append(files, FileStatus{Path: relPath, State: FileMissingRemote})
+			return nil
 		}
 		if err != nil {
 			return fmt.Errorf("cannot access remote file %s: %w", remotePath, err)
 		}
-
-		// Compare inodes
-		localInode := getInode(info)
-		remoteInode := getInode(remoteInfo)
-
-		if localInode == 0 || remoteInode == 0 {
-			return fmt.Errorf("cannot determine inode for %s", localPath)
+		if msg := compareFileIDs(info, remoteInfo); msg != "" {
+			files = append(files, FileStatus{Path: relPath, State: FileDiverged, Error: msg})
+			return nil
 		}
+		files = append(files, FileStatus{Path: relPath, State: FileLinked})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if localInode != remoteInode {
-			return fmt.Errorf("file %s is not hard linked (different inodes)", relPath)
+	err = filepath.Walk(remoteDir, func(remotePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(remoteDir, remotePath)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path: %w", err)
+		}
+		if remotePath != remoteDir {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, relPath), info.IsDir()); skip {
+				return err
+			}
+		}
+		if info.IsDir() || isLnkrInternal(info.Name()) {
+			return nil
+		}
+		if !seen[relPath] {
+			files = append(files, FileStatus{Path: relPath, State: FileMissingLocal})
 		}
-
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
 }
 
-func getInode(fileInfo os.FileInfo) uint64 {
-	sys := fileInfo.Sys()
-	if sys == nil {
-		return 0
+// summarizeFiles counts the files of a hard-linked directory that are not
+// linked, e.g. "2 of 120 file(s) not hard linked (1 diverged, 1
+// missing-local)". It returns "" when all files are linked.
+func summarizeFiles(files []FileStatus) string {
+	counts := make(map[FileState]int)
+	var tracked int
+	for _, f := range files {
+		if f.State != FileUntracked {
+			counts[f.State]++
+			tracked++
+		}
 	}
+	bad := tracked - counts[FileLinked]
+	if bad == 0 {
+		return ""
+	}
+	var details []string
+	for _, state := range []FileState{FileDiverged, FileMissingRemote, FileMissingLocal} {
+		if counts[state] > 0 {
+			details = append(details, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	return fmt.Sprintf("%d of %d file(s) not hard linked (%s)", bad, tracked, strings.Join(details, ", "))
+}
 
-	switch sys := sys.(type) {
-	case *syscall.Stat_t:
-		return sys.Ino
-	default:
-		return 0
+// compareFileIDs returns why local and remote are not the same file, or ""
+// when they are hard links to each other.
+func compareFileIDs(local, remote os.FileInfo) string {
+	localID, ok := getFileID(local)
+	if !ok {
+		return "cannot determine inode"
+	}
+	remoteID, ok := getFileID(remote)
+	if !ok {
+		return "cannot determine inode"
+	}
+	if localID.dev != remoteID.dev {
+		return "different filesystems"
+	}
+	if localID.ino != remoteID.ino {
+		return "different inodes"
 	}
+	return ""
 }
@@ -1,6 +1,7 @@
 package lnkr
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -88,6 +89,11 @@ func TestGetStatusText(t *testing.T) {
 			status: LinkStatus{Exists: true, IsLink: false},
 			want:   "NOT LINKED",
 		},
+		{
+			name:   "CopyLocalChanged",
+			status: LinkStatus{Exists: true, IsLink: true, Sync: SyncLocalChanged},
+			want:   "LOCAL CHANGED",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -109,6 +115,7 @@ func TestCheckLinkStatus(t *testing.T) {
 		noRemote        bool // leave config.Remote empty
 		wantExists      bool
 		wantIsLink      bool
+		wantState       LinkState
 		wantErrContains string // empty means no error expected
 	}{
 		{
@@ -122,6 +129,20 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:       Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			wantExists: true,
 			wantIsLink: true,
+			wantState:  StateLinked,
+		},
+		{
+			name: "SymbolicRelativeLinked",
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+				if err := os.Symlink(filepath.Join("..", "remote", "a.txt"), filepath.Join(localDir, "a.txt")); err != nil {
+					t.Fatalf("failed to create symlink: %v", err)
+				}
+			},
+			link:       Link{Path: "a.txt", Type: LinkTypeSymbolic},
+			wantExists: true,
+			wantIsLink: true,
+			wantState:  StateLinked,
 		},
 		{
 			name: "SymbolicWrongTarget",
@@ -134,6 +155,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			wantExists:      true,
 			wantErrContains: "Wrong target",
+			wantState:       StateWrongTarget,
 		},
 		{
 			name: "SymbolicNotASymlink",
@@ -144,6 +166,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			wantExists:      true,
 			wantErrContains: "Not a symbolic link",
+			wantState:       StateNotLinked,
 		},
 		{
 			name: "SymbolicTargetMissing",
@@ -155,6 +178,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			wantExists:      true,
 			wantErrContains: "TARGET NOT FOUND",
+			wantState:       StateTargetMissing,
 		},
 		{
 			name:            "LinkNotFound",
@@ -162,6 +186,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			wantExists:      false,
 			wantErrContains: "LINK NOT FOUND",
+			wantState:       StateMissing,
 		},
 		{
 			name: "HardLinked",
@@ -174,6 +199,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:       Link{Path: "a.txt", Type: LinkTypeHard},
 			wantExists: true,
 			wantIsLink: true,
+			wantState:  StateLinked,
 		},
 		{
 			name: "HardDifferentInode",
@@ -184,6 +210,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeHard},
 			wantExists:      true,
 			wantErrContains: "Not a hard link",
+			wantState:       StateNotLinked,
 		},
 		{
 			name: "HardTargetMissing",
@@ -193,6 +220,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeHard},
 			wantExists:      true,
 			wantErrContains: "TARGET NOT FOUND",
+			wantState:       StateTargetMissing,
 		},
 		{
 			name: "HardDirectoryLinked",
@@ -210,6 +238,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:       Link{Path: "conf", Type: LinkTypeHard},
 			wantExists: true,
 			wantIsLink: true,
+			wantState:  StateLinked,
 		},
 		{
 			name: "HardDirectoryNotLinked",
@@ -220,6 +249,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "conf", Type: LinkTypeHard},
 			wantExists:      true,
 			wantErrContains: "not hard linked",
+			wantState:       StateNotLinked,
 		},
 		{
 			name:            "LocalNotConfigured",
@@ -227,6 +257,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			noLocal:         true,
 			wantErrContains: "Local directory not configured",
+			wantState:       StateError,
 		},
 		{
 			name:            "RemoteNotConfigured",
@@ -234,6 +265,7 @@ func TestCheckLinkStatus(t *testing.T) {
 			link:            Link{Path: "a.txt", Type: LinkTypeSymbolic},
 			noRemote:        true,
 			wantErrContains: "Remote directory not configured",
+			wantState:       StateError,
 		},
 	}
 
@@ -266,6 +298,9 @@ func TestCheckLinkStatus(t *testing.T) {
 			if status.IsLink != tc.wantIsLink {
 				t.Fatalf("unexpected IsLink: got %v, want %v", status.IsLink, tc.wantIsLink)
 			}
+			if status.State != tc.wantState {
+				t.Fatalf("unexpected State: got %q, want %q", status.State, tc.wantState)
+			}
 			if tc.wantErrContains == "" {
 				if status.Error != "" {
 					t.Fatalf("unexpected error: %q", status.Error)
@@ -276,3 +311,126 @@ func TestCheckLinkStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		links    []Link
+		setup    func(t *testing.T, localDir, remoteDir string)
+		wantCode int // 0 means no error expected
+	}{
+		{
+			name:  "Healthy",
+			links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				if err := os.Symlink(filepath.Join(remoteDir, "a.txt"), filepath.Join(localDir, "a.txt")); err != nil {
+					t.Fatalf("failed to create symlink: %v", err)
+				}
+			},
+		},
+		{
+			name:     "Missing",
+			links:    []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+			setup:    func(t *testing.T, localDir, remoteDir string) {},
+			wantCode: CheckExitMissing,
+		},
+		{
+			name:  "Broken",
+			links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}, {Path: "b.txt", Type: LinkTypeSymbolic}},
+			setup: func(t *testing.T, localDir, remoteDir string) {
+				writeFiles(t, localDir, map[string]string{"a.txt": "a"})
+			},
+			wantCode: CheckExitBroken,
+		},
+		{
+			name:     "InvalidConfig",
+			links:    []Link{{Path: "../a.txt", Type: LinkTypeSymbolic}},
+			setup:    func(t *testing.T, localDir, remoteDir string) {},
+			wantCode: CheckExitConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			localDir, remoteDir := setupProject(t, &Config{Links: tc.links})
+			writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "b.txt": "b"})
+			tc.setup(t, localDir, remoteDir)
+
+			err := CheckStatus(Selector{})
+			if tc.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) || exitErr.Code != tc.wantCode {
+				t.Fatalf("expected exit code %d, got %v", tc.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestCheckHardLinkedDir(t *testing.T) {
+	localDir, remoteDir := setupProject(t, nil)
+	local, remote := filepath.Join(localDir, "conf"), filepath.Join(remoteDir, "conf")
+	writeFiles(t, remote, map[string]string{
+		"linked.txt":       "l",
+		"sub/linked.txt":   "l",
+		"diverged.txt":     "remote",
+		"only-remote.txt":  "r",
+		"a.txt.lnkr-tmp":   "tmp",
+		ConfigFileName:     "",
+		"sub/only-rem.txt": "r",
+		"cache/data.bin":   "c",
+	})
+	writeFiles(t, local, map[string]string{
+		".DS_Store":                     "ds",
+		"diverged.txt":                  "local",
+		"only-local.txt":                "o",
+		"notes.txt.lnkr-backup-2024010": "b",
+	})
+	if err := os.MkdirAll(filepath.Join(local, "sub"), 0755); err != nil {
+		t.Fatalf("failed to create local dir: %v", err)
+	}
+	for _, name := range []string{"linked.txt", "sub/linked.txt"} {
+		if err := os.Link(filepath.Join(remote, name), filepath.Join(local, name)); err != nil {
+			t.Fatalf("failed to create hard link: %v", err)
+		}
+	}
+
+	ignore, err := newIgnoreMatcher([]string{".DS_Store", "/conf/cache/"})
+	if err != nil {
+		t.Fatalf("newIgnoreMatcher failed: %v", err)
+	}
+	files, err := checkHardLinkedDir("conf", local, remote, ignore)
+	if err != nil {
+		t.Fatalf("checkHardLinkedDir failed: %v", err)
+	}
+	got := make(map[string]FileState)
+	for _, f := range files {
+		got[f.Path] = f.State
+	}
+	want := map[string]FileState{
+		"diverged.txt":                  FileDiverged,
+		"linked.txt":                    FileLinked,
+		"notes.txt.lnkr-backup-2024010": FileUntracked,
+		"only-local.txt":                FileMissingRemote,
+		"only-remote.txt":               FileMissingLocal,
+		"sub/linked.txt":                FileLinked,
+		"sub/only-rem.txt":              FileMissingLocal,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected files: got %v, want %v", got, want)
+	}
+	for path, state := range want {
+		if got[path] != state {
+			t.Fatalf("unexpected state of %s: got %q, want %q", path, got[path], state)
+		}
+	}
+
+	summary := summarizeFiles(files)
+	if want := "4 of 6 file(s) not hard linked (1 diverged, 1 missing-remote, 2 missing-local)"; summary != want {
+		t.Fatalf("unexpected summary: got %q, want %q", summary, want)
+	}
+}
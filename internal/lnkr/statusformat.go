@@ -0,0 +1,121 @@
+package lnkr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats of 'lnkr status'. Any other format containing "{{" is a
+// Go template executed for each entry.
+const (
+	StatusFormatTable     = "table"
+	StatusFormatJSON      = "json"
+	StatusFormatYAML      = "yaml"
+	StatusFormatPorcelain = "porcelain"
+)
+
+// StatusReport is the machine-readable output of 'lnkr status'.
+type StatusReport struct {
+	Local  string `json:"local" yaml:"local"`
+	Remote string `json:"remote" yaml:"remote"`
+	// Interrupted is set when the journal of an interrupted operation
+	// exists, see 'lnkr recover'.
+	Interrupted bool         `json:"interrupted" yaml:"interrupted"`
+	Links       []LinkStatus `json:"links" yaml:"links"`
+	// Unmanaged lists the paths no entry covers, with 'lnkr status --all'.
+	Unmanaged []UnmanagedStatus `json:"unmanaged,omitempty" yaml:"unmanaged,omitempty"`
+}
+
+// validStatusFormat reports whether format is a format of 'lnkr status'.
+func validStatusFormat(format string) bool {
+	switch format {
+	case "", StatusFormatTable, StatusFormatJSON, StatusFormatYAML, StatusFormatPorcelain:
+		return true
+	default:
+		return strings.Contains(format, "{{")
+	}
+}
+
+// newStatusReport checks links, entries of config, and with all scans for
+// unmanaged paths too. Local and remote are reported expanded when
+// possible.
+func newStatusReport(config *Config, links []Link, all bool) (StatusReport, error) {
+	report := StatusReport{Local: config.Local, Remote: config.Remote, Links: []LinkStatus{}}
+	if local, err := config.GetLocalExpanded(); err == nil {
+		report.Local = local
+	}
+	if remote, err := config.GetRemoteExpanded(); err == nil {
+		report.Remote = remote
+	}
+	if _, err := os.Lstat(config.journalPath()); err == nil {
+		report.Interrupted = true
+	}
+	for _, link := range links {
+		report.Links = append(report.Links, checkLinkStatus(link, config))
+	}
+	if all {
+		unmanaged, err := scanConfigUnmanaged(config)
+		if err != nil {
+			return report, err
+		}
+		report.Unmanaged = unmanaged
+	}
+	return report, nil
+}
+
+// writeStatusReport writes report to w in format:
+//
+//   - json, yaml: the whole report
+//   - porcelain: one line per entry with its state, type and path separated
+//     by tabs; the path comes last and may contain spaces. Unmanaged paths
+//     follow with "-" as their type
+//   - a Go template such as '{{.Path}} {{.State}}', executed for each entry
+//     (a LinkStatus) and followed by a newline
+func writeStatusReport(w io.Writer, format string, report StatusReport) error {
+	switch format {
+	case StatusFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+	case StatusFormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		return encoder.Close()
+	case StatusFormatPorcelain:
+		for _, s := range report.Links {
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", s.State, s.Type, s.Path); err != nil {
+				return err
+			}
+		}
+		for _, u := range report.Unmanaged {
+			if _, err := fmt.Fprintf(w, "%s\t-\t%s\n", u.State, u.Path); err != nil {
+				return err
+			}
+		}
+	default:
+		tmpl, err := template.New("status").Parse(format)
+		if err != nil {
+			return fmt.Errorf("failed to parse format template: %w", err)
+		}
+		for _, s := range report.Links {
+			if err := tmpl.Execute(w, s); err != nil {
+				return fmt.Errorf("failed to execute format template: %w", err)
+			}
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package lnkr
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestWriteStatusReport(t *testing.T) {
+	report := StatusReport{
+		Local:  "/home/me/project",
+		Remote: "/backup/project",
+		Links: []LinkStatus{
+			{Path: "a.txt", Type: LinkTypeSymbolic, State: StateLinked, Exists: true, IsLink: true},
+			{Path: "my notes.md", Type: LinkTypeHard, State: StateMissing, Error: "LINK NOT FOUND"},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "Porcelain",
+			format: StatusFormatPorcelain,
+			want:   "linked\tsym\ta.txt\nmissing\thard\tmy notes.md\n",
+		},
+		{
+			name:   "Template",
+			format: "{{.Path}} {{.State}}",
+			want:   "a.txt linked\nmy notes.md missing\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeStatusReport(&buf, tc.format, report); err != nil {
+				t.Fatalf("writeStatusReport failed: %v", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Fatalf("unexpected output:\ngot  %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeStatusReport(&buf, StatusFormatJSON, report); err != nil {
+			t.Fatalf("writeStatusReport failed: %v", err)
+		}
+		var got struct {
+			Remote string `json:"remote"`
+			Links  []map[string]any
+		}
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+		}
+		if got.Remote != report.Remote || len(got.Links) != 2 {
+			t.Fatalf("unexpected report: %+v", got)
+		}
+		if got.Links[1]["state"] != "missing" || got.Links[1]["error"] != "LINK NOT FOUND" {
+			t.Fatalf("unexpected entry: %v", got.Links[1])
+		}
+		if _, ok := got.Links[0]["error"]; ok {
+			t.Fatalf("empty error was written: %v", got.Links[0])
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeStatusReport(&buf, StatusFormatYAML, report); err != nil {
+			t.Fatalf("writeStatusReport failed: %v", err)
+		}
+		var got StatusReport
+		if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid YAML: %v\n%s", err, buf.String())
+		}
+		if len(got.Links) != 2 || got.Links[0].State != StateLinked || got.Links[1].Path != "my notes.md" {
+			t.Fatalf("unexpected report: %+v", got)
+		}
+	})
+
+	t.Run("InvalidTemplate", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeStatusReport(&buf, "{{.Path", report); err == nil {
+			t.Fatalf("expected error for an invalid template, but got none")
+		}
+	})
+}
+
+func TestStatusInvalidFormat(t *testing.T) {
+	setupProject(t, &Config{})
+	if err := Status("xml", false, false, Selector{}); err == nil {
+		t.Fatalf("expected error for an unknown format, but got none")
+	}
+}
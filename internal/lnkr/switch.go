@@ -2,25 +2,104 @@ package lnkr
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 )
 
-// Switch changes the link type of an existing entry.
-// If newType is empty, it toggles between sym and hard.
-func Switch(path string, newType string) error {
+// Switch changes the link type of the entries selected by sel, one after
+// the other. A path naming a directory of per-file hard-linked entries
+// selects the directory, which is converted as a whole.
+// If newType is empty, it toggles between sym and hard (copy and reflink
+// switch to sym).
+// With dryRun, the planned actions are printed instead of applied.
+func Switch(sel Selector, newType string, dryRun bool) error {
 	// Normalize "symbolic" to "sym" for backward compatibility
 	if newType == "symbolic" {
 		newType = LinkTypeSymbolic
 	}
 
 	// Validate newType if provided
-	if newType != "" && newType != LinkTypeSymbolic && newType != LinkTypeHard {
-		return fmt.Errorf("invalid link type: %s. Must be '%s' or '%s'", newType, LinkTypeSymbolic, LinkTypeHard)
+	if newType != "" && !ValidLinkType(newType) {
+		return fmt.Errorf("invalid link type: %s. Must be '%s', '%s', '%s' or '%s'", newType, LinkTypeSymbolic, LinkTypeHard, LinkTypeCopy, LinkTypeReflink)
 	}
+	if sel.IsEmpty() {
+		return fmt.Errorf("no entries selected; give a path, a pattern or a selector")
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	paths, err := switchTargets(config, sel)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no matching entries found in configuration")
+	}
+	if len(paths) == 1 {
+		return switchPath(paths[0], newType, dryRun)
+	}
+
+	// Each entry is switched in its own operation, so one that cannot be
+	// switched leaves the others alone
+	var errorCount int
+	for _, path := range paths {
+		if err := switchPath(path, newType, dryRun); err != nil {
+			fmt.Printf("Error switching %s: %v\n", path, err)
+			errorCount++
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("%d of %d entr(ies) could not be switched", errorCount, len(paths))
+	}
+	return nil
+}
+
+// switchTargets returns the paths Switch converts: the selected entries,
+// with the entries inside a directory given as a path replaced by the
+// directory when they are all selected hard links.
+func switchTargets(config *Config, sel Selector) ([]string, error) {
+	selected, err := selectEntries(config, sel)
+	if err != nil {
+		return nil, err
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand local path: %w", err)
+	}
+	resolved, err := sel.resolve(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, p := range resolved {
+		if p.re != nil || slices.ContainsFunc(config.Links, func(l Link) bool { return l.Path == p.rel }) {
+			continue
+		}
+		inside := slices.DeleteFunc(slices.Clone(config.Links), func(l Link) bool { return !p.matches(l.Path) })
+		if len(inside) > 0 && !slices.ContainsFunc(inside, func(l Link) bool { return l.Type != LinkTypeHard || !slices.Contains(selected, l) }) {
+			dirs = append(dirs, p.rel)
+		}
+	}
+
+	paths := slices.Clone(dirs)
+	for _, link := range selected {
+		if !slices.ContainsFunc(dirs, func(dir string) bool { return isManaged(link.Path, []Link{{Path: dir}}) }) {
+			paths = append(paths, link.Path)
+		}
+	}
+	return paths, nil
+}
 
+// switchPath changes the link type of the entry at path, or of the
+// hard-linked directory at path, in one operation.
+func switchPath(path string, newType string, dryRun bool) error {
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -107,114 +186,205 @@ func Switch(path string, newType string) error {
 		return fmt.Errorf("failed to stat remote path: %w", err)
 	}
 
-	if fi.IsDir() || isHardLinkedDir {
-		// Handle directory conversion
-		return switchDirectory(config, targetIndex, path, localDir, remoteDir, currentType, targetType)
+	// Hard links and reflinks cannot span filesystems; refuse before
+	// removing the link
+	if needsSameDevice(targetType, config) {
+		hint := "keep the current link type or move the remote directory to the same filesystem"
+		if targetType == LinkTypeReflink {
+			hint = reflinkFallbackHint
+		}
+		if err := checkSameDevice(localPath, remotePath, targetType, hint); err != nil {
+			return err
+		}
 	}
 
-	// Handle file conversion
-	return switchFile(config, targetIndex, path, localPath, remotePath, currentType, targetType)
-}
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return err
+	}
+	// A local copy is replaced by a link, which must not lose local changes
+	if isCopyType(currentType) && pathExists(localPath) {
+		if err := checkCopyRemovable(path, localPath, remotePath, state, config.ignores); err != nil {
+			return err
+		}
+	}
+
+	// Directories are hard linked file by file; other types link them whole
+	recursive := (fi.IsDir() || isHardLinkedDir) && (currentType == LinkTypeHard || targetType == LinkTypeHard)
 
-// switchFile switches a single file's link type
-func switchFile(config *Config, targetIndex int, path, localPath, remotePath, currentType, targetType string) error {
-	// Remove existing link
-	if err := os.Remove(localPath); err != nil {
-		return fmt.Errorf("failed to remove existing link: %w", err)
+	var plan *Plan
+	if recursive {
+		// Handle directory conversion from or to per-file hard links
+		plan, err = planSwitchDirectory(config, targetIndex, path, localDir, remoteDir, currentType, targetType)
+	} else {
+		// Handle file (or whole directory) conversion
+		plan = planSwitchFile(config, targetIndex, path, localPath, remotePath, currentType, targetType)
+	}
+	if err != nil {
+		return err
 	}
 
-	// Create new link
-	if err := createLink(remotePath, localPath, targetType); err != nil {
-		// Try to restore old link
-		if restoreErr := createLink(remotePath, localPath, currentType); restoreErr != nil {
-			fmt.Printf("Warning: failed to restore original link: %v\n", restoreErr)
+	// Keep the sync state of copies in step with the new type
+	if isCopyType(targetType) {
+		hash, err := hashPath(remotePath, path, config.ignores)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", remotePath, err)
 		}
-		return fmt.Errorf("failed to create new link: %w", err)
+		plan.add(recordAction(state, path, hash))
+	} else if _, ok := state.Copies[path]; ok {
+		plan.add(recordAction(state, path, ""))
+	}
+
+	plan.journal(config, "switch")
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: link type would be switched: %s -> %s for %s\n", currentType, targetType, path)
+		return nil
 	}
 
-	// Update config
-	config.Links[targetIndex].Type = targetType
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
+	if err := plan.Apply(); err != nil {
+		return err
 	}
 
-	fmt.Printf("Switched link type: %s -> %s for %s\n", currentType, targetType, path)
+	if recursive {
+		fmt.Printf("Switched link type: %s -> %s for %s (recursive)\n", currentType, targetType, path)
+	} else {
+		fmt.Printf("Switched link type: %s -> %s for %s\n", currentType, targetType, path)
+	}
 	return nil
 }
 
-// switchDirectory handles directory link type conversion
-func switchDirectory(config *Config, targetIndex int, path, localDir, remoteDir, currentType, targetType string) error {
+// planSwitchFile plans switching a single file's link type. If creating the
+// new link fails, reverting the plan restores the original link.
+func planSwitchFile(config *Config, targetIndex int, path, localPath, remotePath, currentType, targetType string) *Plan {
+	links := slices.Clone(config.Links)
+	links[targetIndex].Type = targetType
+
+	plan := &Plan{}
+	plan.add(
+		Action{Kind: ActionUnlink, Entry: path, Source: remotePath, Target: localPath, LinkType: currentType, Relative: isRelativeSymlink(localPath)},
+		Action{Kind: ActionLink, Entry: path, Source: remotePath, Target: localPath, LinkType: targetType, Relative: config.relativeSymlink(links[targetIndex]), CopyFallback: config.copyFallback(), Ignore: config.ignores},
+		configAction(config, links),
+	)
+	return plan
+}
+
+// planSwitchDirectory plans a directory link type conversion to or from
+// per-file hard links.
+func planSwitchDirectory(config *Config, targetIndex int, path, localDir, remoteDir, currentType, targetType string) (*Plan, error) {
 	localPath := filepath.Join(localDir, path)
 	remotePath := filepath.Join(remoteDir, path)
 
+	plan := &Plan{}
+	var links []Link
+
 	if targetType == LinkTypeHard {
-		// sym -> hard: Remove symlink dir, create hard links for each file
-		if err := os.Remove(localPath); err != nil {
-			return fmt.Errorf("failed to remove symlink directory: %w", err)
-		}
+		// sym/copy -> hard: Remove symlink dir or copy, create hard links
+		// for each file
+		plan.add(Action{Kind: ActionUnlink, Entry: path, Source: remotePath, Target: localPath, LinkType: currentType, Relative: isRelativeSymlink(localPath)})
 
-		// Walk remote directory and create hard links for each file
+		// Walk remote directory and plan hard links for each file that is
+		// not ignored. The local directories do not exist yet once the
+		// link is gone.
 		var newLinks []Link
 		err := filepath.Walk(remotePath, func(p string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
-			if info.IsDir() {
-				relPath, _ := filepath.Rel(remotePath, p)
-				subLocalDir := filepath.Join(localPath, relPath)
-				if relPath == "." {
-					subLocalDir = localPath
+			relPath, err := filepath.Rel(remoteDir, p)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+			if p != remotePath {
+				if skip, err := config.ignores.walkSkip(relPath, info.IsDir()); skip {
+					return err
 				}
-				return os.MkdirAll(subLocalDir, 0755)
 			}
-
-			// Create hard link for file
-			relPath, _ := filepath.Rel(remoteDir, p)
 			localFile := filepath.Join(localDir, relPath)
-			if err := createLink(p, localFile, LinkTypeHard); err != nil {
-				return err
+			if info.IsDir() {
+				plan.add(Action{Kind: ActionMkdir, Entry: path, Target: localFile})
+				return nil
 			}
+
+			plan.add(Action{Kind: ActionLink, Entry: path, Source: p, Target: localFile, LinkType: LinkTypeHard})
 			newLinks = append(newLinks, Link{Path: relPath, Type: LinkTypeHard})
 			return nil
 		})
 		if err != nil {
-			return fmt.Errorf("failed to convert directory to hard links: %w", err)
+			return nil, fmt.Errorf("failed to convert directory to hard links: %w", err)
 		}
 
 		// Remove original directory entry and add new file entries
-		config.Links = append(config.Links[:targetIndex], config.Links[targetIndex+1:]...)
-		config.Links = append(config.Links, newLinks...)
-
+		links = slices.Concat(config.Links[:targetIndex], config.Links[targetIndex+1:], newLinks)
 	} else {
-		// hard -> sym: Simply remove hard links and create symlink dir
+		// hard -> sym/copy: Remove hard links and create symlink dir or
+		// copy. Files that are not registered, ignored ones included, are
+		// never removed and would be in the way of the new link, so the
+		// switch is refused up front.
+		leftover, err := unregisteredFiles(config, path, localDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s for unregistered files: %w", localPath, err)
+		}
+		if len(leftover) > 0 {
+			return nil, fmt.Errorf("cannot switch %s to %s: %d file(s) are not registered and would be in the way: %s; move them out of %s first", path, targetType, len(leftover), strings.Join(leftover, ", "), localPath)
+		}
+
 		pathPrefix := path + string(os.PathSeparator)
-		var remainingLinks []Link
 		for _, link := range config.Links {
 			if link.Path == path || strings.HasPrefix(link.Path, pathPrefix) {
-				_ = os.Remove(filepath.Join(localDir, link.Path))
+				linkAbs := filepath.Join(localDir, link.Path)
+				if _, err := os.Lstat(linkAbs); err == nil {
+					plan.add(Action{Kind: ActionUnlink, Entry: path, Source: filepath.Join(remoteDir, link.Path), Target: linkAbs, LinkType: LinkTypeHard})
+				}
 			} else {
-				remainingLinks = append(remainingLinks, link)
+				links = append(links, link)
 			}
 		}
 
-		// Remove local directory tree and create symbolic link
-		_ = os.RemoveAll(localPath)
-		if err := createLink(remotePath, localPath, LinkTypeSymbolic); err != nil {
-			return fmt.Errorf("failed to create symbolic link: %w", err)
-		}
-
-		remainingLinks = append(remainingLinks, Link{Path: path, Type: LinkTypeSymbolic})
-		config.Links = remainingLinks
+		plan.add(
+			Action{Kind: ActionRmdir, Entry: path, Target: localPath},
+			Action{Kind: ActionLink, Entry: path, Source: remotePath, Target: localPath, LinkType: targetType, Relative: config.relativeSymlink(Link{}), CopyFallback: config.copyFallback(), Ignore: config.ignores},
+		)
+		links = append(links, Link{Path: path, Type: targetType})
 	}
 
-	sort.Slice(config.Links, func(i, j int) bool {
-		return config.Links[i].Path < config.Links[j].Path
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Path < links[j].Path
 	})
 
-	if err := saveConfig(config); err != nil {
-		return fmt.Errorf("failed to save configuration: %w", err)
+	plan.add(configAction(config, links))
+	return plan, nil
+}
+
+// unregisteredFiles returns the files under the hard-linked directory at
+// path, relative to localDir, that are not file entries of config.
+func unregisteredFiles(config *Config, path, localDir string) ([]string, error) {
+	registered := make(map[string]bool)
+	for _, link := range config.Links {
+		if link.Path == path {
+			// Recorded as a whole, the directory holds no file entries
+			return nil, nil
+		}
+		registered[link.Path] = true
 	}
 
-	fmt.Printf("Switched link type: %s -> %s for %s (recursive)\n", currentType, targetType, path)
-	return nil
+	var files []string
+	err := filepath.WalkDir(filepath.Join(localDir, path), func(p string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		if !registered[rel] {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
 }
@@ -3,6 +3,8 @@ package lnkr
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -74,7 +76,7 @@ func TestSwitch(t *testing.T) {
 
 			// Create initial link
 			localPath := filepath.Join(localDir, testFile)
-			if err := createLink(remotePath, localPath, tc.initialType); err != nil {
+			if err := createLink(remotePath, localPath, tc.initialType, false); err != nil {
 				t.Fatalf("failed to create initial link: %v", err)
 			}
 
@@ -106,7 +108,7 @@ func TestSwitch(t *testing.T) {
 			}
 
 			// Run switch
-			err = Switch(testFile, tc.newType)
+			err = Switch(Selector{Paths: []string{testFile}}, tc.newType, false)
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error but got none")
@@ -200,7 +202,7 @@ func TestSwitchDirectorySymToHard(t *testing.T) {
 	}
 
 	// Switch directory sym -> hard (recursive)
-	if err := Switch(testDir, LinkTypeHard); err != nil {
+	if err := Switch(Selector{Paths: []string{testDir}}, LinkTypeHard, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -284,7 +286,7 @@ func TestSwitchDirectoryHardToSym(t *testing.T) {
 	}
 
 	// Switch directory hard -> sym
-	if err := Switch(testDir, LinkTypeSymbolic); err != nil {
+	if err := Switch(Selector{Paths: []string{testDir}}, LinkTypeSymbolic, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -350,8 +352,138 @@ func TestSwitchNotFound(t *testing.T) {
 	}
 
 	// Try to switch non-existent path - should fail
-	err = Switch("nonexistent.txt", LinkTypeHard)
+	err = Switch(Selector{Paths: []string{"nonexistent.txt"}}, LinkTypeHard, false)
 	if err == nil {
 		t.Fatalf("expected error when switching non-existent path, but got none")
 	}
 }
+
+func TestSwitchDryRun(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{{Path: "a.txt", Type: LinkTypeSymbolic}},
+	})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+
+	if err := Switch(Selector{Paths: []string{"a.txt"}}, LinkTypeHard, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The link and the configuration must be unchanged.
+	assertLink(t, filepath.Join(localDir, "a.txt"), filepath.Join(remoteDir, "a.txt"), LinkTypeSymbolic)
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	if config.Links[0].Type != LinkTypeSymbolic {
+		t.Fatalf("config was modified during dry run: %+v", config.Links)
+	}
+}
+
+func TestSwitchDirectoryHardToSymKeepsUnregisteredFiles(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{{Path: "conf/a.txt", Type: LinkTypeHard}},
+	})
+	writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+	writeFiles(t, localDir, map[string]string{"conf/extra.txt": "extra"})
+
+	// The symlink cannot replace a directory holding an unregistered file.
+	err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false)
+	if err == nil {
+		t.Fatalf("expected error but got none")
+	}
+	if !strings.Contains(err.Error(), filepath.Join("conf", "extra.txt")) {
+		t.Fatalf("error does not name the unregistered file: %v", err)
+	}
+
+	// Nothing is changed: the hard link stays, the file survives.
+	assertLink(t, filepath.Join(localDir, "conf", "a.txt"), filepath.Join(remoteDir, "conf", "a.txt"), LinkTypeHard)
+	if _, err := os.Stat(filepath.Join(localDir, "conf", "extra.txt")); err != nil {
+		t.Fatalf("unregistered file was removed: %v", err)
+	}
+}
+
+func TestSwitchDirectoryHardToSymIgnoredFile(t *testing.T) {
+	resetGlobalConfig(t)
+	InitGlobalConfig()
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{{Path: "conf/a.txt", Type: LinkTypeHard}},
+	})
+	writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+	writeFiles(t, localDir, map[string]string{"conf/.DS_Store": "ds"})
+
+	// Ignored files are not removed either, so they block the switch too
+	err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false)
+	if err == nil {
+		t.Fatalf("expected error but got none")
+	}
+	if !strings.Contains(err.Error(), filepath.Join("conf", ".DS_Store")) {
+		t.Fatalf("error does not name the ignored file: %v", err)
+	}
+	assertLink(t, filepath.Join(localDir, "conf", "a.txt"), filepath.Join(remoteDir, "conf", "a.txt"), LinkTypeHard)
+
+	if err := os.Remove(filepath.Join(localDir, "conf", ".DS_Store")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertLink(t, filepath.Join(localDir, "conf"), filepath.Join(remoteDir, "conf"), LinkTypeSymbolic)
+}
+
+func TestSwitchSelected(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{
+			{Path: "data/a.txt", Type: LinkTypeSymbolic},
+			{Path: "data/b.txt", Type: LinkTypeSymbolic},
+			{Path: "data/c.txt", Type: LinkTypeCopy},
+			{Path: "other.txt", Type: LinkTypeSymbolic},
+		},
+	})
+	writeFiles(t, remoteDir, map[string]string{"data/a.txt": "a", "data/b.txt": "b", "data/c.txt": "c", "other.txt": "o"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+
+	if err := Switch(Selector{Paths: []string{"data"}, Types: []string{LinkTypeSymbolic}}, LinkTypeHard, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to reload config: %v", err)
+	}
+	want := []Link{
+		{Path: "data/a.txt", Type: LinkTypeHard},
+		{Path: "data/b.txt", Type: LinkTypeHard},
+		{Path: "data/c.txt", Type: LinkTypeCopy},
+		{Path: "other.txt", Type: LinkTypeSymbolic},
+	}
+	if !slices.Equal(config.Links, want) {
+		t.Fatalf("unexpected links: got %+v, want %+v", config.Links, want)
+	}
+	for _, link := range []Link{want[0], want[1], want[3]} {
+		assertLink(t, filepath.Join(localDir, link.Path), filepath.Join(remoteDir, link.Path), link.Type)
+	}
+
+	// data is not switched as a whole since data/c.txt is not selected;
+	// its hard links are switched one by one
+	if err := Switch(Selector{Paths: []string{"data/"}, Types: []string{LinkTypeHard}}, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{"data/a.txt", "data/b.txt"} {
+		assertLink(t, filepath.Join(localDir, path), filepath.Join(remoteDir, path), LinkTypeSymbolic)
+	}
+
+	if err := Switch(Selector{}, LinkTypeHard, false); err == nil {
+		t.Fatal("expected error for an empty selection")
+	}
+}
@@ -0,0 +1,58 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// relativeLinkTarget returns source relative to the directory of the link
+// at link. Symlinked parent directories are resolved first so ".." steps
+// follow the physical tree the kernel walks; when they cannot be resolved
+// (e.g. the link's directory does not exist yet) the paths are used as is.
+// source is returned unchanged when no relative path exists.
+func relativeLinkTarget(source, link string) string {
+	dir, src := filepath.Dir(link), source
+	if resolvedDir, err := filepath.EvalSymlinks(dir); err == nil {
+		if resolvedSrc, err := filepath.EvalSymlinks(filepath.Dir(source)); err == nil {
+			dir, src = resolvedDir, filepath.Join(resolvedSrc, filepath.Base(source))
+		}
+	}
+	rel, err := filepath.Rel(dir, src)
+	if err != nil {
+		return source
+	}
+	return rel
+}
+
+// linkTargetPath returns the path a symbolic link at link with the given
+// target refers to, resolving a relative target against the link's
+// directory.
+func linkTargetPath(link, target string) string {
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return filepath.Join(filepath.Dir(link), target)
+}
+
+// symlinkPointsTo reports whether the symbolic link at link, whose target
+// is target, points to want. Targets that only differ in symlinked
+// directories on the way (including ".." steps out of them) count as the
+// same.
+func symlinkPointsTo(link, target, want string) bool {
+	if linkTargetPath(link, target) == filepath.Clean(want) {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return false
+	}
+	resolvedWant, err := filepath.EvalSymlinks(want)
+	return err == nil && resolved == resolvedWant
+}
+
+// isRelativeSymlink reports whether path is a symbolic link with a relative
+// target.
+func isRelativeSymlink(path string) bool {
+	target, err := os.Readlink(path)
+	return err == nil && !filepath.IsAbs(target)
+}
@@ -0,0 +1,165 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelativeLinkTarget(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		link   string
+		want   string
+	}{
+		{
+			name:   "SiblingTrees",
+			source: "/home/me/Dropbox/lnkr/proj/file",
+			link:   "/home/me/src/proj/file",
+			want:   "../../Dropbox/lnkr/proj/file",
+		},
+		{
+			name:   "NestedEntry",
+			source: "/data/remote/conf/app.toml",
+			link:   "/data/local/conf/app.toml",
+			want:   "../../remote/conf/app.toml",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := relativeLinkTarget(filepath.FromSlash(tc.source), filepath.FromSlash(tc.link))
+			if got != filepath.FromSlash(tc.want) {
+				t.Fatalf("unexpected target: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRelativeLinkTargetResolvesSymlinkedParent(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFiles(t, tempDir, map[string]string{"real/remote/a.txt": "a"})
+	if err := os.MkdirAll(filepath.Join(tempDir, "real", "local"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	// local is reached through a symlinked directory, so ".." from it leads
+	// to real, not to tempDir
+	if err := os.Symlink(filepath.Join(tempDir, "real", "local"), filepath.Join(tempDir, "local")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	link := filepath.Join(tempDir, "local", "a.txt")
+	source := filepath.Join(tempDir, "real", "remote", "a.txt")
+	target := relativeLinkTarget(source, link)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+	if got := readFile(t, link); got != "a" {
+		t.Fatalf("link %s -> %s does not reach the source: got %q", link, target, got)
+	}
+}
+
+func TestSymlinkPointsTo(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFiles(t, tempDir, map[string]string{"remote/a.txt": "a", "remote/b.txt": "b"})
+	if err := os.MkdirAll(filepath.Join(tempDir, "local"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	link := filepath.Join(tempDir, "local", "a.txt")
+	want := filepath.Join(tempDir, "remote", "a.txt")
+
+	testCases := []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{name: "Absolute", target: want, want: true},
+		{name: "Relative", target: filepath.Join("..", "remote", "a.txt"), want: true},
+		{name: "RelativeUnclean", target: filepath.Join("..", "remote", ".", "a.txt"), want: true},
+		{name: "OtherFile", target: filepath.Join("..", "remote", "b.txt"), want: false},
+		{name: "Missing", target: filepath.Join("..", "remote", "c.txt"), want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_ = os.Remove(link)
+			if err := os.Symlink(tc.target, link); err != nil {
+				t.Fatalf("failed to create symlink: %v", err)
+			}
+			if got := symlinkPointsTo(link, tc.target, want); got != tc.want {
+				t.Fatalf("symlinkPointsTo(%q) = %v, want %v", tc.target, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRelativeSymlinkStyle(t *testing.T) {
+	localDir, _ := setupProject(t, &Config{SymlinkStyle: SymlinkStyleRelative})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a"})
+
+	if err := Add([]string{"conf/a.txt"}, false, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	localPath := filepath.Join(localDir, "conf", "a.txt")
+	target, err := os.Readlink(localPath)
+	if err != nil {
+		t.Fatalf("failed to read link: %v", err)
+	}
+	if want := filepath.Join("..", "..", "remote", "conf", "a.txt"); target != want {
+		t.Fatalf("unexpected link target: got %q, want %q", target, want)
+	}
+
+	// Linking again recognizes the relative link
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("CreateLinks failed: %v", err)
+	}
+
+	// Switching away and back keeps the style
+	if err := Switch(Selector{Paths: []string{"conf/a.txt"}}, LinkTypeHard, false); err != nil {
+		t.Fatalf("Switch to hard failed: %v", err)
+	}
+	if err := Switch(Selector{Paths: []string{"conf/a.txt"}}, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("Switch to sym failed: %v", err)
+	}
+	if got, _ := os.Readlink(localPath); got != target {
+		t.Fatalf("unexpected link target after switch: got %q, want %q", got, target)
+	}
+
+	// The link survives moving the parent shared by local and remote
+	projectDir := filepath.Dir(localDir)
+	movedDir := projectDir + "-moved"
+	if err := os.Rename(projectDir, movedDir); err != nil {
+		t.Fatalf("failed to move project: %v", err)
+	}
+	t.Chdir(movedDir)
+	if got := readFile(t, filepath.Join(movedDir, "local", "conf", "a.txt")); got != "a" {
+		t.Fatalf("unexpected content through moved link: got %q", got)
+	}
+	status := checkLinkStatus(Link{Path: "conf/a.txt", Type: LinkTypeSymbolic}, &Config{
+		Local:  filepath.Join(movedDir, "local"),
+		Remote: filepath.Join(movedDir, "remote"),
+	})
+	if !status.IsLink || status.Error != "" {
+		t.Fatalf("expected link to be reported as linked, got %+v", status)
+	}
+}
+
+func TestSymlinkStyleOverride(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		SymlinkStyle: SymlinkStyleRelative,
+		Links: []Link{
+			{Path: "a.txt", Type: LinkTypeSymbolic},
+			{Path: "b.txt", Type: LinkTypeSymbolic, SymlinkStyle: SymlinkStyleAbsolute},
+		},
+	})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "b.txt": "b"})
+
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("CreateLinks failed: %v", err)
+	}
+	if !isRelativeSymlink(filepath.Join(localDir, "a.txt")) {
+		t.Fatalf("expected a relative link for a.txt")
+	}
+	assertLink(t, filepath.Join(localDir, "b.txt"), filepath.Join(remoteDir, "b.txt"), LinkTypeSymbolic)
+}
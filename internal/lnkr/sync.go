@@ -0,0 +1,188 @@
+package lnkr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Push copies the local content of copy and reflink entries to remote. Entries whose
+// remote changed since the last sync are refused unless force is set. With
+// no paths, all copy entries are pushed.
+func Push(paths []string, force, dryRun bool) error {
+	return syncCopies(paths, true, force, dryRun)
+}
+
+// Pull copies the remote content of copy and reflink entries to local. Entries whose
+// local copy changed since the last sync are refused unless force is set.
+// With no paths, all copy entries are pulled.
+func Pull(paths []string, force, dryRun bool) error {
+	return syncCopies(paths, false, force, dryRun)
+}
+
+func syncCopies(paths []string, push, force, dryRun bool) error {
+	operation, title := "pull", "Pull"
+	if push {
+		operation, title = "push", "Push"
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	links, err := selectCopyLinks(config.Links, paths, localDir)
+	if err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		fmt.Printf("No copy links found in %s\n", ConfigFileName)
+		return nil
+	}
+
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return err
+	}
+
+	var errorCount int
+	plan := &Plan{}
+	for _, link := range links {
+		actions, err := planSyncEntry(link, filepath.Join(localDir, link.Path), filepath.Join(remoteDir, link.Path), push, force, config.copyFallback(), state, config.ignores)
+		if err != nil {
+			fmt.Printf("Error: cannot %s %s: %v\n", operation, link.Path, err)
+			errorCount++
+			continue
+		}
+		plan.add(actions...)
+	}
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d copy link(s) would be updated, %d error(s).\n", plan.count(ActionReplace), errorCount)
+		return nil
+	}
+
+	plan.journal(config, operation)
+	failed, err := plan.ApplyEach(func(entry string, err error) {
+		fmt.Printf("Error: cannot %s %s: %v\n", operation, entry, err)
+	})
+	if err != nil {
+		return err
+	}
+	errorCount += failed
+
+	if errorCount > 0 {
+		return fmt.Errorf("%d of %d copy link(s) failed to %s", errorCount, len(links), operation)
+	}
+	fmt.Printf("%s completed. (%d copy link(s))\n", title, len(links))
+	return nil
+}
+
+// selectCopyLinks returns the copy entries matching paths (an entry or a
+// directory containing entries), or all copy entries when paths is empty.
+func selectCopyLinks(links []Link, paths []string, localDir string) ([]Link, error) {
+	if len(paths) == 0 {
+		var selected []Link
+		for _, link := range links {
+			if isCopyType(link.Type) {
+				selected = append(selected, link)
+			}
+		}
+		return selected, nil
+	}
+
+	var selected []Link
+	seen := make(map[string]struct{})
+	for _, p := range paths {
+		// Normalize the input path (trailing slash, "./" prefix, CWD-relative)
+		if resolved, err := resolveLocalRelPath(p, localDir); err == nil {
+			p = resolved
+		} else {
+			p = filepath.Clean(p)
+		}
+
+		var found bool
+		for _, link := range links {
+			if !isCopyType(link.Type) {
+				continue
+			}
+			if link.Path != p && !strings.HasPrefix(link.Path, p+string(os.PathSeparator)) {
+				continue
+			}
+			found = true
+			if _, ok := seen[link.Path]; !ok {
+				seen[link.Path] = struct{}{}
+				selected = append(selected, link)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no copy link found for %s", p)
+		}
+	}
+	return selected, nil
+}
+
+// planSyncEntry plans copying one side of a copy entry over the other. The
+// overwritten side must not have changed since the last sync unless force
+// is set.
+func planSyncEntry(link Link, localPath, remotePath string, push, force, copyFallback bool, state *syncState, ignore *ignoreMatcher) ([]Action, error) {
+	from, to := remotePath, localPath
+	fromName, toName := "remote", "local"
+	toChanged, otherCommand := SyncLocalChanged, "push"
+	if push {
+		from, to = localPath, remotePath
+		fromName, toName = "local", "remote"
+		toChanged, otherCommand = SyncRemoteChanged, "pull"
+	}
+
+	if fi, err := os.Lstat(localPath); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("not a copy: %s", localPath)
+	}
+	if _, err := os.Lstat(from); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s copy not found: %s", fromName, from)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", from, err)
+	}
+
+	fromHash, err := hashPath(from, link.Path, ignore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", from, err)
+	}
+
+	var actions []Action
+	if _, err := os.Lstat(to); os.IsNotExist(err) {
+		if parent := filepath.Dir(to); !pathExists(parent) {
+			actions = append(actions, Action{Kind: ActionMkdir, Entry: link.Path, Target: parent})
+		}
+	} else {
+		status, _, _, err := copyStatus(link.Path, localPath, remotePath, state, ignore)
+		if err != nil {
+			return nil, err
+		}
+		if status == SyncInSync {
+			fmt.Printf("Already in sync: %s\n", link.Path)
+			if state.Copies[link.Path] == fromHash {
+				return nil, nil
+			}
+			return []Action{recordAction(state, link.Path, fromHash)}, nil
+		}
+		if (status == toChanged || status == SyncBothChanged) && !force {
+			return nil, fmt.Errorf("%s has changes that would be overwritten (%s); run 'lnkr %s' first or use --force", toName, status, otherCommand)
+		}
+	}
+
+	return append(actions,
+		Action{Kind: ActionReplace, Entry: link.Path, Source: from, Target: to, LinkType: link.Type, CopyFallback: copyFallback, Ignore: ignore},
+		recordAction(state, link.Path, fromHash),
+	), nil
+}
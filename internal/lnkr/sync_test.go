@@ -0,0 +1,150 @@
+package lnkr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyStatusText returns the status text of the copy entry at path.
+func copyStatusText(t *testing.T, path string) string {
+	t.Helper()
+
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return getStatusText(checkLinkStatus(Link{Path: path, Type: LinkTypeCopy}, config))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(content)
+}
+
+func TestCopyPushPull(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"settings.json": "v1"})
+	localPath := filepath.Join(localDir, "settings.json")
+	remotePath := filepath.Join(remoteDir, "settings.json")
+
+	if err := Add([]string{localPath}, false, LinkTypeCopy, false); err != nil {
+		t.Fatalf("unexpected error on add: %v", err)
+	}
+	if fi, err := os.Lstat(localPath); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("expected local path to be a regular file: %v", err)
+	}
+	if got := readFile(t, remotePath); got != "v1" {
+		t.Fatalf("unexpected remote content: %q", got)
+	}
+	if got := copyStatusText(t, "settings.json"); got != SyncInSync {
+		t.Fatalf("unexpected status after add: %q", got)
+	}
+
+	// A local change is pushed; pulling would overwrite it and is refused.
+	writeFiles(t, localDir, map[string]string{"settings.json": "v2"})
+	if got := copyStatusText(t, "settings.json"); got != SyncLocalChanged {
+		t.Fatalf("unexpected status after local change: %q", got)
+	}
+	if err := Pull(nil, false, false); err == nil {
+		t.Fatalf("expected pull to refuse overwriting local changes")
+	}
+	if err := Push(nil, false, false); err != nil {
+		t.Fatalf("unexpected error on push: %v", err)
+	}
+	if got := readFile(t, remotePath); got != "v2" {
+		t.Fatalf("unexpected remote content after push: %q", got)
+	}
+	if got := copyStatusText(t, "settings.json"); got != SyncInSync {
+		t.Fatalf("unexpected status after push: %q", got)
+	}
+
+	// A remote change is pulled; pushing would overwrite it and is refused.
+	writeFiles(t, remoteDir, map[string]string{"settings.json": "v3"})
+	if got := copyStatusText(t, "settings.json"); got != SyncRemoteChanged {
+		t.Fatalf("unexpected status after remote change: %q", got)
+	}
+	if err := Push([]string{"settings.json"}, false, false); err == nil {
+		t.Fatalf("expected push to refuse overwriting remote changes")
+	}
+	if err := Pull([]string{"settings.json"}, false, false); err != nil {
+		t.Fatalf("unexpected error on pull: %v", err)
+	}
+	if got := readFile(t, localPath); got != "v3" {
+		t.Fatalf("unexpected local content after pull: %q", got)
+	}
+
+	// Changes on both sides need --force.
+	writeFiles(t, localDir, map[string]string{"settings.json": "local"})
+	writeFiles(t, remoteDir, map[string]string{"settings.json": "remote"})
+	if got := copyStatusText(t, "settings.json"); got != SyncBothChanged {
+		t.Fatalf("unexpected status after both changed: %q", got)
+	}
+	if err := Push(nil, false, false); err == nil {
+		t.Fatalf("expected push to refuse when both changed")
+	}
+	if err := Push(nil, true, false); err != nil {
+		t.Fatalf("unexpected error on forced push: %v", err)
+	}
+	if got := readFile(t, remotePath); got != "local" {
+		t.Fatalf("unexpected remote content after forced push: %q", got)
+	}
+}
+
+func TestCopyUnlinkKeepsLocalChanges(t *testing.T) {
+	localDir, _ := setupProject(t, &Config{Links: []Link{}})
+	writeFiles(t, localDir, map[string]string{"conf/a.txt": "a"})
+	localPath := filepath.Join(localDir, "conf")
+
+	if err := Add([]string{localPath}, false, LinkTypeCopy, false); err != nil {
+		t.Fatalf("unexpected error on add: %v", err)
+	}
+
+	// A local copy with unpushed changes must survive unlink and switch.
+	writeFiles(t, localDir, map[string]string{"conf/b.txt": "new"})
+	if err := Unlink(Selector{}, false, true); err != nil {
+		t.Fatalf("unexpected error on unlink: %v", err)
+	}
+	if got := readFile(t, filepath.Join(localPath, "b.txt")); got != "new" {
+		t.Fatalf("expected local change to be kept, got %q", got)
+	}
+	err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false)
+	if err == nil || !strings.Contains(err.Error(), "lnkr push") {
+		t.Fatalf("expected switch to refuse with a push hint, got %v", err)
+	}
+
+	// Once pushed, the copy can be switched to a symbolic link.
+	if err := Push(nil, false, false); err != nil {
+		t.Fatalf("unexpected error on push: %v", err)
+	}
+	if err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeSymbolic, false); err != nil {
+		t.Fatalf("unexpected error on switch: %v", err)
+	}
+	fi, err := os.Lstat(localPath)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected a symbolic link after switch: %v", err)
+	}
+	if got := readFile(t, filepath.Join(localPath, "b.txt")); got != "new" {
+		t.Fatalf("unexpected content through symlink: %q", got)
+	}
+	if _, err := os.Stat(StateFileName); !os.IsNotExist(err) {
+		t.Fatalf("expected sync state to be forgotten, got %v", err)
+	}
+
+	// Switching back to copy unlinks the symlink and copies the tree.
+	if err := Switch(Selector{Paths: []string{"conf"}}, LinkTypeCopy, false); err != nil {
+		t.Fatalf("unexpected error on switch to copy: %v", err)
+	}
+	if fi, err := os.Lstat(localPath); err != nil || !fi.IsDir() {
+		t.Fatalf("expected a directory copy after switch: %v", err)
+	}
+	if got := copyStatusText(t, "conf"); got != SyncInSync {
+		t.Fatalf("unexpected status after switch to copy: %q", got)
+	}
+}
@@ -8,9 +8,10 @@ import (
 	"strings"
 )
 
-// Unlink removes the links at local while keeping the entries in the
-// configuration and the files in remote. Use 'lnkr link' to re-create them.
-func Unlink(dryRun, assumeYes bool) error {
+// Unlink removes the links at local of the entries selected by sel while
+// keeping the entries in the configuration and the files in remote. Use
+// 'lnkr link' to re-create them.
+func Unlink(sel Selector, dryRun, assumeYes bool) error {
 	config, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
@@ -20,6 +21,14 @@ func Unlink(dryRun, assumeYes bool) error {
 		fmt.Printf("No links found in %s\n", ConfigFileName)
 		return nil
 	}
+	selected, err := selectEntries(config, sel)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Println("No matching links found.")
+		return nil
+	}
 
 	// Use local directory as base for resolving link paths
 	localDir, err := config.GetLocalExpanded()
@@ -31,112 +40,139 @@ func Unlink(dryRun, assumeYes bool) error {
 		return fmt.Errorf("failed to expand remote path: %w", err)
 	}
 
-	if dryRun {
-		for _, link := range config.Links {
-			fmt.Printf("Would remove link: %s (type: %s)\n", filepath.Join(localDir, link.Path), link.Type)
+	state, err := loadSyncState(config.statePath())
+	if err != nil {
+		return err
+	}
+
+	var errorCount int
+	plan := &Plan{}
+	for _, link := range selected {
+		actions, err := planUnlinkEntry(link, localDir, remoteDir, state, config.ignores)
+		if err != nil {
+			fmt.Printf("Error removing link for %s: %v\n", link.Path, err)
+			errorCount++
+			continue
 		}
-		fmt.Printf("Dry run: %d link(s) would be removed.\n", len(config.Links))
-		return nil
+		plan.add(actions...)
 	}
 
-	if !assumeYes && !confirm(fmt.Sprintf("Remove %d link(s) under %s?", len(config.Links), localDir)) {
-		fmt.Println("Aborted.")
+	// Remove all link paths from GitExclude once nothing is linked; the
+	// entries of a partial unlink stay excluded
+	if len(selected) == len(config.Links) {
+		excludePath := config.GetGitExcludePath()
+		plan.add(Action{Kind: ActionExclude, Target: excludePath, Prev: config, Optional: true})
+	}
+
+	if dryRun {
+		plan.Print()
+		fmt.Printf("Dry run: %d link(s) would be removed.\n", plan.count(ActionUnlink))
 		return nil
 	}
 
-	for _, link := range config.Links {
-		if err := removeLinkEntry(link, localDir, remoteDir); err != nil {
-			fmt.Printf("Error removing link for %s: %v\n", link.Path, err)
-			continue
-		}
+	if !assumeYes && !confirm(fmt.Sprintf("Remove %d link(s) under %s?", len(selected), localDir)) {
+		fmt.Println("Aborted.")
+		return nil
 	}
 
-	// Remove all link paths from GitExclude
-	if err := removeAllLinksFromGitExclude(config); err != nil {
-		fmt.Printf("Warning: failed to remove link paths from GitExclude: %v\n", err)
+	plan.journal(config, "unlink")
+	if _, err := plan.ApplyEach(func(entry string, err error) {
+		fmt.Printf("Error removing link for %s: %v\n", entry, err)
+	}); err != nil {
+		return err
 	}
 
 	fmt.Println("Link removal completed.")
 	return nil
 }
 
-func removeLinkEntry(link Link, localDir, remoteDir string) error {
+// planUnlinkEntry plans removing the local link of a single entry. A missing
+// local path yields no actions. A local copy is only removed when it holds
+// no changes missing from remote. Ignored files in hard-linked directories
+// are left alone.
+func planUnlinkEntry(link Link, localDir, remoteDir string, state *syncState, ignore *ignoreMatcher) ([]Action, error) {
 	// Resolve absolute path for link
 	linkAbs := filepath.Join(localDir, link.Path)
+	remoteAbs := filepath.Join(remoteDir, link.Path)
 
-	if _, err := os.Lstat(linkAbs); os.IsNotExist(err) {
+	fi, err := os.Lstat(linkAbs)
+	if os.IsNotExist(err) {
 		fmt.Printf("Path does not exist, skipping: %s\n", linkAbs)
-		return nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
 	}
 
 	switch link.Type {
 	case LinkTypeHard:
-		info, err := os.Stat(linkAbs)
-		if err != nil {
-			return fmt.Errorf("failed to stat path: %w", err)
+		if fi.IsDir() {
+			return planUnlinkHardLinkedDir(link.Path, linkAbs, remoteAbs, ignore)
 		}
-
-		if info.IsDir() {
-			return removeHardLinkedDir(linkAbs, filepath.Join(remoteDir, link.Path))
+	case LinkTypeSymbolic:
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return nil, fmt.Errorf("not a symbolic link: %s", linkAbs)
 		}
-		if err := os.Remove(linkAbs); err != nil {
-			return fmt.Errorf("failed to remove hard link: %w", err)
+	case LinkTypeCopy, LinkTypeReflink:
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("not a copy: %s", linkAbs)
 		}
-		fmt.Printf("Removed hard link: %s\n", linkAbs)
-	case LinkTypeSymbolic:
-		if err := os.Remove(linkAbs); err != nil {
-			return fmt.Errorf("failed to remove symbolic link: %w", err)
+		if err := checkCopyRemovable(link.Path, linkAbs, remoteAbs, state, ignore); err != nil {
+			return nil, err
 		}
-		fmt.Printf("Removed symbolic link: %s\n", linkAbs)
+		return []Action{
+			{Kind: ActionUnlink, Entry: link.Path, Source: remoteAbs, Target: linkAbs, LinkType: link.Type},
+			recordAction(state, link.Path, ""),
+		}, nil
 	default:
-		return fmt.Errorf("unknown link type: %s", link.Type)
+		return nil, fmt.Errorf("unknown link type: %s", link.Type)
 	}
 
-	return nil
+	return []Action{{Kind: ActionUnlink, Entry: link.Path, Source: remoteAbs, Target: linkAbs, LinkType: link.Type, Relative: isRelativeSymlink(linkAbs)}}, nil
 }
 
-// removeHardLinkedDir removes only the files that are hard links to the
-// corresponding remote files. Unrelated files added after linking are kept so
-// unlink never destroys data that exists nowhere else.
-func removeHardLinkedDir(localDirPath, remoteDirPath string) error {
+// planUnlinkHardLinkedDir plans removing only the files that are hard links
+// to the corresponding remote files. Unrelated files added after linking are
+// kept so unlink never destroys data that exists nowhere else.
+func planUnlinkHardLinkedDir(entry, localDirPath, remoteDirPath string, ignore *ignoreMatcher) ([]Action, error) {
+	var actions []Action
 	var kept int
 	err := filepath.Walk(localDirPath, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			return nil
-		}
-
 		relPath, err := filepath.Rel(localDirPath, p)
 		if err != nil {
 			return fmt.Errorf("failed to get relative path: %w", err)
 		}
+		if p != localDirPath {
+			if skip, err := ignore.walkSkip(filepath.Join(entry, relPath), info.IsDir()); skip {
+				return err
+			}
+		}
+		if info.IsDir() {
+			return nil
+		}
 
-		remoteInfo, err := os.Stat(filepath.Join(remoteDirPath, relPath))
+		remotePath := filepath.Join(remoteDirPath, relPath)
+		remoteInfo, err := os.Stat(remotePath)
 		if err == nil && os.SameFile(info, remoteInfo) {
-			if err := os.Remove(p); err != nil {
-				return fmt.Errorf("failed to remove hard link %s: %w", p, err)
-			}
-			fmt.Printf("Removed hard link: %s\n", p)
+			actions = append(actions, Action{Kind: ActionUnlink, Entry: entry, Source: remotePath, Target: p, LinkType: LinkTypeHard})
 			return nil
 		}
 
 		kept++
-		fmt.Printf("Kept (not linked to remote): %s\n", p)
+		fmt.Printf("Keeping (not linked to remote): %s\n", p)
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	removeEmptyDirTree(localDirPath)
 	if kept > 0 {
-		fmt.Printf("Kept %d file(s) under %s\n", kept, localDirPath)
-	} else {
-		fmt.Printf("Removed directory: %s\n", localDirPath)
+		fmt.Printf("Keeping %d file(s) under %s\n", kept, localDirPath)
 	}
-	return nil
+	return append(actions, Action{Kind: ActionRmdir, Entry: entry, Target: localDirPath}), nil
 }
 
 // removeEmptyDirTree removes root and its subdirectories bottom-up,
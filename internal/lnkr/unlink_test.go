@@ -3,6 +3,7 @@ package lnkr
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -35,11 +36,11 @@ func TestUnlink(t *testing.T) {
 			localDir, remoteDir := setupProject(t, &Config{Links: tc.links})
 			writeFiles(t, remoteDir, tc.remoteFiles)
 
-			if err := CreateLinks(); err != nil {
+			if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 				t.Fatalf("failed to create links: %v", err)
 			}
 
-			if err := Unlink(false, true); err != nil {
+			if err := Unlink(Selector{}, false, true); err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
@@ -73,14 +74,14 @@ func TestUnlinkKeepsUnlinkedFilesInHardDir(t *testing.T) {
 	})
 	writeFiles(t, remoteDir, map[string]string{"conf/a.txt": "a"})
 
-	if err := CreateLinks(); err != nil {
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 		t.Fatalf("failed to create links: %v", err)
 	}
 
 	// A file added after linking is not a hard link to remote.
 	writeFiles(t, localDir, map[string]string{"conf/extra.txt": "extra"})
 
-	if err := Unlink(false, true); err != nil {
+	if err := Unlink(Selector{}, false, true); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -103,11 +104,11 @@ func TestUnlinkDryRun(t *testing.T) {
 	})
 	writeFiles(t, remoteDir, map[string]string{"a.txt": "a"})
 
-	if err := CreateLinks(); err != nil {
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
 		t.Fatalf("failed to create links: %v", err)
 	}
 
-	if err := Unlink(true, false); err != nil {
+	if err := Unlink(Selector{}, true, false); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -115,12 +116,46 @@ func TestUnlinkDryRun(t *testing.T) {
 	assertLink(t, filepath.Join(localDir, "a.txt"), filepath.Join(remoteDir, "a.txt"), LinkTypeSymbolic)
 }
 
+func TestUnlinkSelected(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{
+		Links: []Link{
+			{Path: ".vscode/settings.json", Type: LinkTypeSymbolic},
+			{Path: "a.txt", Type: LinkTypeSymbolic},
+			{Path: "b.txt", Type: LinkTypeHard},
+		},
+	})
+	writeFiles(t, remoteDir, map[string]string{".vscode/settings.json": "{}", "a.txt": "a", "b.txt": "b"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("failed to create links: %v", err)
+	}
+
+	if err := Unlink(Selector{Paths: []string{".vscode"}}, false, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Unlink(Selector{Types: []string{LinkTypeHard}}, false, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{".vscode/settings.json", "b.txt"} {
+		if _, err := os.Lstat(filepath.Join(localDir, path)); !os.IsNotExist(err) {
+			t.Fatalf("selected link still exists: %s", path)
+		}
+	}
+	assertLink(t, filepath.Join(localDir, "a.txt"), filepath.Join(remoteDir, "a.txt"), LinkTypeSymbolic)
+
+	// Entries of a partial unlink stay in the git exclude section.
+	entries := gitExcludeSectionEntries(t, GitExcludePath)
+	if !slices.Contains(entries, "/b.txt") {
+		t.Fatalf("git exclude section lost entries: %v", entries)
+	}
+}
+
 func TestUnlinkMissingLocalSkipped(t *testing.T) {
 	setupProject(t, &Config{
 		Links: []Link{{Path: "ghost.txt", Type: LinkTypeSymbolic}},
 	})
 
-	if err := Unlink(false, true); err != nil {
+	if err := Unlink(Selector{}, false, true); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
@@ -128,7 +163,7 @@ func TestUnlinkMissingLocalSkipped(t *testing.T) {
 func TestUnlinkNoLinks(t *testing.T) {
 	setupProject(t, &Config{Links: []Link{}})
 
-	if err := Unlink(false, true); err != nil {
+	if err := Unlink(Selector{}, false, true); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
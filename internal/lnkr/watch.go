@@ -0,0 +1,341 @@
+package lnkr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits after the last event on a file
+// before checking it, so saves made of several steps settle first.
+const watchDebounce = 200 * time.Millisecond
+
+// watchedFile is a hard-linked file kept intact by Watch.
+type watchedFile struct {
+	entry  string
+	local  string
+	remote string
+}
+
+// watchedPath is one side of a watched file.
+type watchedPath struct {
+	file  *watchedFile
+	local bool
+}
+
+// Watch watches the local and remote paths of all entries until ctx is
+// done. When a hard-linked file is replaced on one side, e.g. by an editor
+// that saves to a temporary file and renames it over the original, the new
+// file is taken to the other side so both are linked again. Events and
+// repairs are logged to out.
+func Watch(ctx context.Context, out io.Writer) error {
+	logger := log.New(out, "", log.LstdFlags)
+
+	config, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	localDir, err := config.GetLocalExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand local path: %w", err)
+	}
+	remoteDir, err := config.GetRemoteExpanded()
+	if err != nil {
+		return fmt.Errorf("failed to expand remote path: %w", err)
+	}
+
+	paths, dirs, err := collectWatchedPaths(config.Links, localDir, remoteDir, config.ignores)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watcher: %w", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	var watching int
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			logger.Printf("Warning: cannot watch %s: %v", dir, err)
+			continue
+		}
+		watching++
+	}
+	logger.Printf("Watching %d entr(ies) in %d director(ies)", len(config.Links), watching)
+
+	// Files that diverged while nobody was watching cannot tell which side
+	// is newer from an event
+	for path, p := range paths {
+		if p.local && diverged(p.file) {
+			logger.Printf("Not hard linked: %s; run 'lnkr repair' to fix it", path)
+		}
+	}
+
+	due := make(chan string)
+	stopped := make(chan struct{})
+	timers := make(map[string]*time.Timer)
+	defer func() {
+		close(stopped)
+		for _, t := range timers {
+			t.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Printf("Stopping")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if _, ok := paths[event.Name]; !ok {
+				continue
+			}
+			logger.Printf("%s %s", event.Op, event.Name)
+			name := event.Name
+			if t, ok := timers[name]; ok {
+				t.Reset(watchDebounce)
+				continue
+			}
+			timers[name] = time.AfterFunc(watchDebounce, func() {
+				select {
+				case due <- name:
+				case <-stopped:
+				}
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Printf("Warning: %v", err)
+		case name := <-due:
+			delete(timers, name)
+			p := paths[name]
+			relinked, err := relinkReplaced(p.file, p.local)
+			if err != nil {
+				logger.Printf("Error: cannot re-link %s: %v", p.file.entry, err)
+				continue
+			}
+			if !relinked {
+				continue
+			}
+			if p.local {
+				logger.Printf("Pushed %s to remote and re-linked it", p.file.local)
+			} else {
+				logger.Printf("Pulled %s from remote and re-linked it", p.file.local)
+			}
+		}
+	}
+}
+
+// collectWatchedPaths returns the hard-linked files of links by their local
+// and remote paths, and the directories to watch: the parents of every
+// entry on both sides and, for hard-linked directories, every directory in
+// them. Directories that do not exist and ignored paths are skipped.
+func collectWatchedPaths(links []Link, localDir, remoteDir string, ignore *ignoreMatcher) (map[string]watchedPath, []string, error) {
+	paths := make(map[string]watchedPath)
+	dirSet := make(map[string]struct{})
+	addDir := func(dir string) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirSet[dir] = struct{}{}
+		}
+	}
+	addFile := func(entry, local, remote string) {
+		f := &watchedFile{entry: entry, local: local, remote: remote}
+		paths[local] = watchedPath{file: f, local: true}
+		paths[remote] = watchedPath{file: f, local: false}
+	}
+
+	for _, link := range links {
+		localPath := filepath.Join(localDir, link.Path)
+		remotePath := filepath.Join(remoteDir, link.Path)
+		addDir(filepath.Dir(localPath))
+		addDir(filepath.Dir(remotePath))
+		if link.Type != LinkTypeHard {
+			continue
+		}
+
+		info, err := os.Stat(remotePath)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			addFile(link.Path, localPath, remotePath)
+			continue
+		}
+		err = filepath.Walk(remotePath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(remotePath, p)
+			if err != nil {
+				return fmt.Errorf("failed to get relative path: %w", err)
+			}
+			if p != remotePath {
+				if skip, err := ignore.walkSkip(filepath.Join(link.Path, rel), info.IsDir()); skip {
+					return err
+				}
+			}
+			if info.IsDir() {
+				addDir(p)
+				addDir(filepath.Join(localPath, rel))
+				return nil
+			}
+			addFile(link.Path, filepath.Join(localPath, rel), p)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to walk %s: %w", remotePath, err)
+		}
+	}
+
+	dirs := make([]string, 0, len(dirSet))
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return paths, dirs, nil
+}
+
+// diverged reports whether both sides of f exist as regular files that are
+// no longer hard links to each other.
+func diverged(f *watchedFile) bool {
+	localInfo, err := os.Lstat(f.local)
+	if err != nil || !localInfo.Mode().IsRegular() {
+		return false
+	}
+	remoteInfo, err := os.Lstat(f.remote)
+	if err != nil || !remoteInfo.Mode().IsRegular() {
+		return false
+	}
+	return !os.SameFile(localInfo, remoteInfo)
+}
+
+// relinkReplaced makes the other side of f a hard link to the side that
+// was replaced (local when fromLocal is set). The new link is created next
+// to the other side and renamed over it, so that path never goes missing.
+// It reports whether anything was done; files that are still linked, or
+// missing on either side, are left alone.
+func relinkReplaced(f *watchedFile, fromLocal bool) (bool, error) {
+	if !diverged(f) {
+		return false, nil
+	}
+	src, dst := f.remote, f.local
+	if fromLocal {
+		src, dst = f.local, f.remote
+	}
+
+	tmp := dst + copySuffix
+	_ = os.Remove(tmp)
+	if err := os.Link(src, tmp); err != nil {
+		return false, fmt.Errorf("failed to create hard link: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return false, fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+	return true, nil
+}
+
+// InstallSystemdUserUnit writes a systemd user unit running 'lnkr watch'
+// for the current project and returns its path. The unit is not enabled.
+func InstallSystemdUserUnit() (string, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	projectDir, err := filepath.Abs(filepath.Dir(config.path()))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate lnkr executable: %w", err)
+	}
+
+	unitDir, err := systemdUserUnitDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", unitDir, err)
+	}
+	unitPath := filepath.Join(unitDir, systemdUnitName(projectDir))
+	if err := os.WriteFile(unitPath, []byte(systemdUnit(exe, projectDir)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", unitPath, err)
+	}
+	return unitPath, nil
+}
+
+// systemdUserUnitDir returns the directory of systemd user units.
+func systemdUserUnitDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+// systemdUnitName returns the unit name for watching projectDir. The hash
+// of the full path keeps projects with the same directory name apart.
+func systemdUnitName(projectDir string) string {
+	sum := sha256.Sum256([]byte(projectDir))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, filepath.Base(projectDir))
+	return fmt.Sprintf("lnkr-watch-%s-%s.service", name, hex.EncodeToString(sum[:4]))
+}
+
+// systemdUnit returns the content of a user unit running exe watch in
+// projectDir.
+func systemdUnit(exe, projectDir string) string {
+	return fmt.Sprintf(`[Unit]
+Description=lnkr watch for %s
+
+[Service]
+Type=simple
+WorkingDirectory=%s
+ExecStart=%s watch
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, systemdEscape(projectDir), systemdEscape(projectDir), systemdQuote(exe))
+}
+
+// systemdEscape escapes the % of specifiers in a unit file value. Paths
+// such as WorkingDirectory= are taken as they are, spaces included, and
+// must not be quoted.
+func systemdEscape(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// systemdQuote quotes a path for a command line of a unit file when it
+// contains spaces, and escapes specifiers and variables.
+func systemdQuote(path string) string {
+	path = strings.ReplaceAll(systemdEscape(path), "$", "$$")
+	if !strings.ContainsAny(path, " \t\"\\") {
+		return path
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path) + `"`
+}
@@ -0,0 +1,234 @@
+package lnkr
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// atomicSave replaces path with new content the way editors do: by writing
+// a temporary file and renaming it over the original.
+func atomicSave(t *testing.T, path, content string) {
+	t.Helper()
+
+	tmp := path + ".swp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("failed to rename %s: %v", tmp, err)
+	}
+}
+
+// hardLinked reports whether a and b are the same file.
+func hardLinked(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+func TestRelinkReplaced(t *testing.T) {
+	testCases := []struct {
+		name        string
+		replace     string // "local", "remote" or "" to keep the link
+		removeLocal bool
+		fromLocal   bool
+		want        bool
+		wantData    string
+	}{
+		{name: "LocalReplaced", replace: "local", fromLocal: true, want: true, wantData: "new"},
+		{name: "RemoteReplaced", replace: "remote", fromLocal: false, want: true, wantData: "new"},
+		{name: "StillLinked", fromLocal: true, want: false, wantData: "old"},
+		{name: "LocalRemoved", removeLocal: true, fromLocal: true, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			f := &watchedFile{entry: "a.txt", local: filepath.Join(tempDir, "local.txt"), remote: filepath.Join(tempDir, "remote.txt")}
+			writeFiles(t, tempDir, map[string]string{"remote.txt": "old"})
+			if err := os.Link(f.remote, f.local); err != nil {
+				t.Fatalf("failed to create hard link: %v", err)
+			}
+			switch tc.replace {
+			case "local":
+				atomicSave(t, f.local, "new")
+			case "remote":
+				atomicSave(t, f.remote, "new")
+			}
+			if tc.removeLocal {
+				_ = os.Remove(f.local)
+			}
+
+			got, err := relinkReplaced(f, tc.fromLocal)
+			if err != nil {
+				t.Fatalf("relinkReplaced failed: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("relinkReplaced() = %v, want %v", got, tc.want)
+			}
+			if tc.wantData == "" {
+				return
+			}
+			if !hardLinked(f.local, f.remote) {
+				t.Fatalf("expected %s and %s to be hard linked", f.local, f.remote)
+			}
+			if got := readFile(t, f.remote); got != tc.wantData {
+				t.Fatalf("unexpected content: got %q, want %q", got, tc.wantData)
+			}
+			if _, err := os.Lstat(f.remote + copySuffix); !os.IsNotExist(err) {
+				t.Fatalf("temporary link was left behind")
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a logger
+// and reads of a test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	localDir, remoteDir := setupProject(t, &Config{Links: []Link{
+		{Path: "a.txt", Type: LinkTypeHard},
+		{Path: "conf/b.txt", Type: LinkTypeHard},
+	}})
+	writeFiles(t, remoteDir, map[string]string{"a.txt": "a", "conf/b.txt": "b"})
+	if err := CreateLinks(Selector{}, false, false, ConflictError); err != nil {
+		t.Fatalf("CreateLinks failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, out) }()
+	waitFor(t, "watch to start", func() bool { return strings.Contains(out.String(), "Watching") })
+
+	localA := filepath.Join(localDir, "a.txt")
+	remoteA := filepath.Join(remoteDir, "a.txt")
+	atomicSave(t, localA, "edited locally")
+	waitFor(t, "a.txt to be re-linked", func() bool { return hardLinked(localA, remoteA) })
+	if got := readFile(t, remoteA); got != "edited locally" {
+		t.Fatalf("unexpected remote content: got %q", got)
+	}
+
+	localB := filepath.Join(localDir, "conf", "b.txt")
+	remoteB := filepath.Join(remoteDir, "conf", "b.txt")
+	atomicSave(t, remoteB, "edited remotely")
+	waitFor(t, "conf/b.txt to be re-linked", func() bool { return hardLinked(localB, remoteB) })
+	if got := readFile(t, localB); got != "edited remotely" {
+		t.Fatalf("unexpected local content: got %q", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Watch did not stop after cancellation")
+	}
+	if log := out.String(); !strings.Contains(log, "Pushed") || !strings.Contains(log, "Pulled") {
+		t.Fatalf("expected repairs to be logged, got:\n%s", log)
+	}
+}
+
+func TestInstallSystemdUserUnit(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dir            string // project directory name
+		wantWorkingDir string // WorkingDirectory= value relative to its parent
+	}{
+		{name: "Plain", dir: "project", wantWorkingDir: "project"},
+		{name: "SpaceAndSpecifier", dir: "my project 100%", wantWorkingDir: "my project 100%%"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := t.TempDir()
+			projectDir := filepath.Join(parent, tc.dir)
+			if err := os.MkdirAll(projectDir, 0755); err != nil {
+				t.Fatalf("failed to create dir: %v", err)
+			}
+			t.Chdir(projectDir)
+			if err := saveConfig(&Config{Local: projectDir, Remote: filepath.Join(parent, "remote")}); err != nil {
+				t.Fatalf("failed to save config: %v", err)
+			}
+			configHome := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", configHome)
+
+			path, err := InstallSystemdUserUnit()
+			if err != nil {
+				t.Fatalf("InstallSystemdUserUnit failed: %v", err)
+			}
+			if dir := filepath.Join(configHome, "systemd", "user"); filepath.Dir(path) != dir {
+				t.Fatalf("unit written to %s, want a file in %s", path, dir)
+			}
+			if !strings.HasPrefix(filepath.Base(path), "lnkr-watch-") || !strings.HasSuffix(path, ".service") || strings.Contains(filepath.Base(path), " ") {
+				t.Fatalf("unexpected unit name: %s", filepath.Base(path))
+			}
+
+			unit := readFile(t, path)
+			wantDir := "WorkingDirectory=" + filepath.Join(parent, tc.wantWorkingDir) + "\n"
+			for _, want := range []string{wantDir, " watch\n", "WantedBy=default.target"} {
+				if !strings.Contains(unit, want) {
+					t.Fatalf("unit does not contain %q:\n%s", want, unit)
+				}
+			}
+		})
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{path: "/usr/bin/lnkr", want: "/usr/bin/lnkr"},
+		{path: "/opt/my tools/lnkr", want: `"/opt/my tools/lnkr"`},
+		{path: "/opt/100%/lnkr", want: "/opt/100%%/lnkr"},
+		{path: "/opt/$HOME/lnkr", want: "/opt/$$HOME/lnkr"},
+	}
+
+	for _, tc := range testCases {
+		if got := systemdQuote(tc.path); got != tc.want {
+			t.Fatalf("systemdQuote(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}